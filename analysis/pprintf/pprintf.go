@@ -0,0 +1,255 @@
+// Package pprintf defines an Analyzer that checks calls of problems.Pprintf
+// and of the functions that wrap it.
+package pprintf
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+
+	"github.com/halliday/go-problems/internal/verbs"
+)
+
+const Doc = `check calls of problems.Pprintf and its wrappers
+
+Pprintf formats its leading args with printf verbs and returns the
+remaining args as key-value data. The pprintf checker reports calls with
+a constant format that have fewer args than the format reads, an odd
+number of key-value args, keys that are not constant strings, and
+duplicate keys.
+
+A function is a wrapper of Pprintf if its last two parameters are
+(format string, args ...any) and it passes them to Pprintf or another
+wrapper as (format, args...), like the wrappers of fmt.Printf that vet
+detects. Wrappers in other packages are found through facts.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "pprintf",
+	Doc:       Doc,
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(isWrapper)},
+}
+
+// isWrapper is the fact of functions that pass their format and args to Pprintf.
+type isWrapper struct{}
+
+func (*isWrapper) AFact() {}
+
+func (*isWrapper) String() string { return "pprintfWrapper" }
+
+const problemsPath = "github.com/halliday/go-problems"
+
+// isPprintf reports whether fn is a function of package problems that formats like Pprintf.
+func isPprintf(fn *types.Func) bool {
+	if fn.Pkg() == nil || fn.Pkg().Path() != problemsPath {
+		return false
+	}
+	switch fn.Name() {
+	case "Pprintf", "TryPprintf":
+		return fn.Type().(*types.Signature).Recv() == nil
+	}
+	return false
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	wrappers := findWrappers(pass, inspect)
+	for fn := range wrappers {
+		pass.ExportObjectFact(fn, new(isWrapper))
+	}
+
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, _ := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if fn == nil {
+			return
+		}
+		fn = fn.Origin()
+		if isPprintf(fn) || wrappers[fn] || pass.ImportObjectFact(fn, new(isWrapper)) {
+			checkCall(pass, call, fn)
+		}
+	})
+	return nil, nil
+}
+
+// findWrappers returns the functions of the package that wrap Pprintf.
+// Wrappers of wrappers in the same package are found by repeating the search.
+func findWrappers(pass *analysis.Pass, inspect *inspector.Inspector) map[*types.Func]bool {
+	type candidate struct {
+		fn     *types.Func
+		body   *ast.BlockStmt
+		format *types.Var
+		args   *types.Var
+	}
+	var candidates []candidate
+	inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		decl := n.(*ast.FuncDecl)
+		if decl.Body == nil {
+			return
+		}
+		fn, _ := pass.TypesInfo.Defs[decl.Name].(*types.Func)
+		if fn == nil {
+			return
+		}
+		format, args := formatParams(fn)
+		if format != nil {
+			candidates = append(candidates, candidate{fn, decl.Body, format, args})
+		}
+	})
+
+	wrappers := make(map[*types.Func]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, c := range candidates {
+			if wrappers[c.fn] {
+				continue
+			}
+			ast.Inspect(c.body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || wrappers[c.fn] {
+					return !wrappers[c.fn]
+				}
+				callee, _ := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+				if callee == nil {
+					return true
+				}
+				callee = callee.Origin()
+				if !isPprintf(callee) && !wrappers[callee] && !pass.ImportObjectFact(callee, new(isWrapper)) {
+					return true
+				}
+				if forwards(pass, call, c.format, c.args) {
+					wrappers[c.fn] = true
+					changed = true
+				}
+				return true
+			})
+		}
+	}
+	return wrappers
+}
+
+// formatParams returns the (format string, args ...any) parameters of fn, if it has them.
+func formatParams(fn *types.Func) (format, args *types.Var) {
+	sig := fn.Type().(*types.Signature)
+	params := sig.Params()
+	if !sig.Variadic() || params.Len() < 2 {
+		return nil, nil
+	}
+	format, args = params.At(params.Len()-2), params.At(params.Len()-1)
+	if !types.Identical(format.Type().Underlying(), types.Typ[types.String]) {
+		return nil, nil
+	}
+	elem := args.Type().(*types.Slice).Elem()
+	if iface, ok := elem.Underlying().(*types.Interface); !ok || !iface.Empty() {
+		return nil, nil
+	}
+	return format, args
+}
+
+// forwards reports whether call passes format and args... as its last two arguments.
+func forwards(pass *analysis.Pass, call *ast.CallExpr, format, args *types.Var) bool {
+	if !call.Ellipsis.IsValid() || len(call.Args) < 2 {
+		return false
+	}
+	return isVar(pass, call.Args[len(call.Args)-2], format) && isVar(pass, call.Args[len(call.Args)-1], args)
+}
+
+func isVar(pass *analysis.Pass, expr ast.Expr, v *types.Var) bool {
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.X
+	}
+	id, ok := expr.(*ast.Ident)
+	return ok && pass.TypesInfo.Uses[id] == v
+}
+
+// checkCall checks a call of Pprintf or a wrapper fn.
+func checkCall(pass *analysis.Pass, call *ast.CallExpr, fn *types.Func) {
+	sig := fn.Type().(*types.Signature)
+	formatIndex := sig.Params().Len() - 2
+	if call.Ellipsis.IsValid() || len(call.Args) <= formatIndex {
+		return
+	}
+	tv := pass.TypesInfo.Types[call.Args[formatIndex]]
+	if tv.Value == nil || tv.Value.Kind() != constant.String {
+		return
+	}
+	name := fn.Name()
+	format := constant.StringVal(tv.Value)
+	args := call.Args[formatIndex+1:]
+
+	n, _ := verbs.Scan(format)
+	if len(args) < n {
+		pass.Reportf(call.Lparen, "%s format %s reads %d args, but call has %d", name, strconv.Quote(format), n, len(args))
+		return
+	}
+	data := args[n:]
+	if len(data) > 0 && isError(pass.TypesInfo.TypeOf(data[len(data)-1])) {
+		data = data[:len(data)-1]
+	}
+
+	switch {
+	case len(data) == 0:
+		return
+	case len(data) == 1:
+		if !isMapOrStruct(pass.TypesInfo.TypeOf(data[0])) {
+			pass.Reportf(data[0].Pos(), "%s call has a single data arg of type %s, want a map[string]any, a struct or key-value pairs", name, pass.TypesInfo.TypeOf(data[0]))
+		}
+		return
+	case len(data)%2 != 0:
+		pass.Reportf(data[len(data)-1].Pos(), "%s call has an odd number of key-value args", name)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(data); i += 2 {
+		key := data[i]
+		tv := pass.TypesInfo.Types[key]
+		if tv.Value == nil || tv.Value.Kind() != constant.String {
+			if basic, ok := tv.Type.Underlying().(*types.Basic); ok && basic.Info()&types.IsString != 0 {
+				pass.Reportf(key.Pos(), "%s call has a non-constant key", name)
+			} else {
+				pass.Reportf(key.Pos(), "%s call has a key of type %s, want a constant string", name, tv.Type)
+			}
+			continue
+		}
+		k := constant.StringVal(tv.Value)
+		if seen[k] {
+			pass.Reportf(key.Pos(), "%s call has a duplicate key %s", name, strconv.Quote(k))
+		}
+		seen[k] = true
+	}
+}
+
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+func isError(t types.Type) bool {
+	return t != nil && types.Implements(t, errorType)
+}
+
+func isMapOrStruct(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	switch t := t.Underlying().(type) {
+	case *types.Struct:
+		return true
+	case *types.Map:
+		elem, ok := t.Elem().Underlying().(*types.Interface)
+		return types.Identical(t.Key(), types.Typ[types.String]) && ok && elem.Empty()
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package pprintf_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/halliday/go-problems/analysis/pprintf"
+)
+
+func Test(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), pprintf.Analyzer, "a", "b")
+}
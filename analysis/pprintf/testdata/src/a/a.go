@@ -0,0 +1,58 @@
+package a
+
+import (
+	"errors"
+
+	"b"
+
+	"github.com/halliday/go-problems"
+)
+
+var ErrX = &problems.Error{}
+
+func calls(key string, err error) {
+	problems.Pprintf("%d of %d", 1, 2, "a", 1)
+	problems.Pprintf("%d of %d", 1)       // want `Pprintf format "%d of %d" reads 2 args, but call has 1`
+	problems.TryPprintf("%[2]d", 1)       // want `TryPprintf format "%\[2\]d" reads 2 args, but call has 1`
+	problems.Pprintf("x", "a", 1, "b")    // want `Pprintf call has an odd number of key-value args`
+	problems.Pprintf("x", key, 1)         // want `Pprintf call has a non-constant key`
+	problems.Pprintf("x", 1, 2)           // want `Pprintf call has a key of type int, want a constant string`
+	problems.Pprintf("x", "a", 1, "a", 2) // want `Pprintf call has a duplicate key "a"`
+	problems.Pprintf("x", 42)             // want `Pprintf call has a single data arg of type int, want a map\[string\]any, a struct or key-value pairs`
+	problems.Pprintf("x", map[string]any{"a": 1})
+	problems.Pprintf("x", struct{ A int }{1})
+	problems.Pprintf("%w", err, "a", 1, errors.New("y"))
+	problems.Pprintf("x: %s", "y", "a", 1, err)
+	problems.Pprintf("%[0]d", "a", 1)
+}
+
+func fail(format string, args ...any) string { // want fail:"pprintfWrapper"
+	s, _, _ := problems.Pprintf(format, args...)
+	return s
+}
+
+func failTwice(format string, args ...any) string { // want failTwice:"pprintfWrapper"
+	return fail(format, args...)
+}
+
+type handler struct{}
+
+func (handler) Failf(status int, format string, args ...any) error { // want Failf:"pprintfWrapper"
+	return ErrX.Errorf(format, args...)
+}
+
+// notWrapper changes the format.
+func notWrapper(format string, args ...any) {
+	problems.Pprintf("prefix: "+format, args...)
+}
+
+func wrappers(key string) {
+	fail("%s", "x", "a", 1, "k")   // want `fail call has an odd number of key-value args`
+	failTwice("x", "a", 1, "a", 2) // want `failTwice call has a duplicate key "a"`
+	handler{}.Failf(400, "%d")     // want `Failf format "%d" reads 1 args, but call has 0`
+	ErrX.Errorf("x", key, 1)       // want `Errorf call has a non-constant key`
+	b.Fail("%d %d", 1)             // want `Fail format "%d %d" reads 2 args, but call has 1`
+	fail("x", "a", 1)
+	b.Fail("%d", 1, "a", 2)
+	notWrapper("%d")
+}
@@ -0,0 +1,12 @@
+package b
+
+import "github.com/halliday/go-problems"
+
+func Fail(format string, args ...any) error { // want Fail:"pprintfWrapper"
+	return problems.Error{}.Errorf(format, args...)
+}
+
+// notWrapper does not forward its args.
+func notWrapper(format string, args ...any) error {
+	return problems.Error{}.Errorf(format)
+}
@@ -0,0 +1,21 @@
+// Package problems is a stub of the functions that the pprintf checker knows.
+package problems
+
+func Pprintf(format string, args ...any) (str string, data map[string]any, wraps error) {
+	return format, nil, nil
+}
+
+func TryPprintf(format string, args ...any) (str string, data map[string]any, wraps error, err error) {
+	return format, nil, nil, nil
+}
+
+type Error struct {
+	Detail string
+}
+
+func (e Error) Error() string { return e.Detail }
+
+func (e Error) Errorf(format string, args ...any) *Error {
+	e.Detail, _, _ = Pprintf(format, args...)
+	return &e
+}
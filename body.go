@@ -0,0 +1,90 @@
+package problems
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Problems returned by DecodeJSON, in addition to the problems of the built-in mappers.
+var (
+	ErrUnsupportedMediaType = &Error{Type: "unsupported-media-type", Status: http.StatusUnsupportedMediaType, Title: "The request content type is not supported."}
+	ErrUnknownField         = &Error{Type: "unknown-field", Status: http.StatusBadRequest, Title: "The request contains an unknown field."}
+	ErrEmptyBody            = &Error{Type: "empty-body", Status: http.StatusBadRequest, Title: "The request body is empty."}
+)
+
+// MaxBodySize is the maximum size in bytes of request bodies read by DecodeJSON.
+var MaxBodySize int64 = 1 << 20
+
+// DecodeJSON decodes the json body of req into v.
+// The request must have the application/json content type or a +json suffix,
+// the body must not exceed MaxBodySize, and it must hold a single json value without unknown fields.
+// All failures are returned as problems that wrap the underlying error:
+// ErrUnsupportedMediaType, ErrRequestTooLarge, ErrEmptyBody, ErrInvalidJSON,
+// ErrInvalidJSONType and ErrUnknownField, with members like "field", "offset" and "expected".
+func DecodeJSON(resp http.ResponseWriter, req *http.Request, v any) error {
+	contentType := req.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return ErrUnsupportedMediaType.Errorf("Expected a JSON body but got %q.", contentType, "contentType", contentType, "expected", "application/json")
+	}
+
+	body := &countingReader{r: http.MaxBytesReader(resp, req.Body, MaxBodySize)}
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		offset := dec.InputOffset()
+		if err == io.ErrUnexpectedEOF {
+			offset = body.n
+		}
+		return jsonBodyError(err, offset)
+	}
+	offset := dec.InputOffset()
+	if _, err := dec.Token(); err != io.EOF {
+		if p := mapMaxBytesError(err); p != nil {
+			return p.(error)
+		}
+		return ErrInvalidJSON.Errorf("The request body must contain a single JSON value, found more after offset %d.", offset, "offset", offset)
+	}
+	return nil
+}
+
+// jsonBodyError returns the problem for err, returned by json.Decoder at offset.
+func jsonBodyError(err error, offset int64) error {
+	switch {
+	case err == io.EOF:
+		return ErrEmptyBody.Errorf("Expected a JSON value.", err)
+	case err == io.ErrUnexpectedEOF:
+		return ErrInvalidJSON.Errorf("Unexpected end of JSON at offset %d.", offset, "offset", offset, err)
+	}
+	if p := mapMaxBytesError(err); p != nil {
+		return p.(error)
+	}
+	if p := mapJSONError(err); p != nil {
+		return p.(error)
+	}
+	// the decoder reports unknown fields as `json: unknown field "name"`
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if field, err := strconv.Unquote(name); err == nil {
+			name = field
+		}
+		return ErrUnknownField.Errorf("Unknown field %q.", name, "field", name, "offset", offset, err)
+	}
+	return fmt.Errorf("problem: can not read request body: %w", err)
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
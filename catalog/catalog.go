@@ -0,0 +1,412 @@
+// Package catalog reads catalogs of problem types from json, yaml, toml, csv
+// and markdown files with front matter, as used by cmd/goproblems.
+// Catalogs can be loaded from any fs.FS, like an embed.FS, to create problems at runtime
+// without code generation:
+//
+//	//go:embed codes/*.md
+//	var codes embed.FS
+//
+//	errs, err := catalog.Load(codes, "codes/*.md")
+//	...
+//	return errs.New("out-of-credits").Errorf("Cannot withdraw %.2f.", amount)
+package catalog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	"github.com/halliday/go-problems"
+)
+
+// Catalog is a list of problem types.
+type Catalog []*Entry
+
+// Entry is a problem type of a catalog.
+type Entry struct {
+	Type     string         `json:"type" yaml:"type" toml:"type"`
+	Title    string         `json:"title" yaml:"title" toml:"title"`
+	Status   int            `json:"status" yaml:"status" toml:"status"`
+	Code     int            `json:"code,omitempty" yaml:"code,omitempty" toml:"code,omitempty"`
+	Detail   string         `json:"detail,omitempty" yaml:"detail,omitempty" toml:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty" yaml:"instance,omitempty" toml:"instance,omitempty"`
+	Data     map[string]any `json:"data,omitempty" yaml:"data,omitempty" toml:"data,omitempty"`
+
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty" toml:"headers,omitempty"`
+	RetryAfter int               `json:"retryAfter,omitempty" yaml:"retryAfter,omitempty" toml:"retryAfter,omitempty"` // seconds
+}
+
+// Header returns the default http response headers of e, including Retry-After.
+func (e *Entry) Header() http.Header {
+	if len(e.Headers) == 0 && e.RetryAfter == 0 {
+		return nil
+	}
+	h := make(http.Header, len(e.Headers)+1)
+	for k, v := range e.Headers {
+		h.Set(k, v)
+	}
+	if e.RetryAfter != 0 {
+		h.Set("Retry-After", strconv.Itoa(e.RetryAfter))
+	}
+	return h
+}
+
+// New returns a new problems.Error of the type e.
+func (e *Entry) New() *problems.Error {
+	var data map[string]any
+	if e.Data != nil {
+		data = make(map[string]any, len(e.Data))
+		for k, v := range e.Data {
+			data[k] = v
+		}
+	}
+	return &problems.Error{
+		Type:     e.Type,
+		Status:   e.Status,
+		Code:     e.Code,
+		Title:    e.Title,
+		Detail:   e.Detail,
+		Instance: e.Instance,
+		Data:     data,
+		Header:   e.Header(),
+	}
+}
+
+// Lookup returns the entry with the given type, or nil if there is none.
+func (c Catalog) Lookup(typ string) *Entry {
+	for _, e := range c {
+		if e.Type == typ {
+			return e
+		}
+	}
+	return nil
+}
+
+// New returns a new problems.Error of the given type.
+// A type that is not in the catalog is a bug of the caller: it is logged,
+// and an about:blank problem with status 500 is returned instead.
+// Use Lookup to check for a type.
+func (c Catalog) New(typ string) *problems.Error {
+	if e := c.Lookup(typ); e != nil {
+		return e.New()
+	}
+	logger := problems.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Error("catalog: unknown problem type", "type", typ)
+	return &problems.Error{Type: "about:blank", Status: http.StatusInternalServerError, Title: http.StatusText(http.StatusInternalServerError)}
+}
+
+// Errors returns a new problems.Error for every entry, by type.
+func (c Catalog) Errors() map[string]*problems.Error {
+	m := make(map[string]*problems.Error, len(c))
+	for _, e := range c {
+		m[e.Type] = e.New()
+	}
+	return m
+}
+
+// Register adds a new problems.Error for every entry to the registry of package problems.
+func (c Catalog) Register() {
+	for _, e := range c {
+		problems.Register(e.New())
+	}
+}
+
+// CSVOptions configure the parsing of csv catalogs, like the fields of csv.Reader.
+type CSVOptions struct {
+	TrimLeadingSpace bool
+	Comment          rune // zero for no comments
+	LazyQuotes       bool
+	Comma            rune // defaults to ','
+}
+
+// DefaultCSVOptions are used by Load.
+var DefaultCSVOptions = CSVOptions{Comment: '#', Comma: ','}
+
+// Loader loads catalogs with the given options.
+type Loader struct {
+	CSV CSVOptions
+}
+
+// Load loads a catalog with DefaultCSVOptions. See Loader.Load.
+func Load(fsys fs.FS, pattern string) (Catalog, error) {
+	l := Loader{CSV: DefaultCSVOptions}
+	return l.Load(fsys, pattern)
+}
+
+// Load loads the catalog at pattern in fsys:
+//   - a pattern with wildcards, like "codes/*.md", matches files with one problem type each
+//   - a directory holds files with one problem type each
+//   - a single file holds a list of problem types, in json, yaml, toml or csv
+//
+// The types of files with one problem type default to the file name without extension,
+// and their titles to the text of their status.
+func (l *Loader) Load(fsys fs.FS, pattern string) (Catalog, error) {
+	if strings.ContainsAny(pattern, "*?[") {
+		return l.loadGlob(fsys, pattern)
+	}
+	info, err := fs.Stat(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return l.loadDir(fsys, pattern)
+	}
+	return l.loadFile(fsys, pattern)
+}
+
+func (l *Loader) loadGlob(fsys fs.FS, pattern string) (Catalog, error) {
+	matches, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	c := make(Catalog, 0, len(matches))
+	var errs []error
+	for _, match := range matches {
+		e, err := readEntry(fsys, match)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			c = append(c, e)
+		}
+	}
+	return c, errors.Join(errs...)
+}
+
+func (l *Loader) loadDir(fsys fs.FS, dir string) (Catalog, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	c := make(Catalog, 0, len(files))
+	var errs []error
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		e, err := readEntry(fsys, path.Join(dir, file.Name()))
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			c = append(c, e)
+		}
+	}
+	return c, errors.Join(errs...)
+}
+
+func (l *Loader) loadFile(fsys fs.FS, name string) (Catalog, error) {
+	ext := path.Ext(name)
+	file, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	c := make(Catalog, 0, 1)
+	switch ext {
+	case ".json":
+		if err := json.Unmarshal(file, &c); err != nil {
+			return nil, fmt.Errorf("can not parse %q as json: %w", name, err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(file, &c); err != nil {
+			return nil, fmt.Errorf("can not parse %q as yaml: %w", name, err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(file, &c); err != nil {
+			return nil, fmt.Errorf("can not parse %q as toml: %w", name, err)
+		}
+	case ".csv":
+		if err := l.unmarshalCSV(file, &c); err != nil {
+			return nil, fmt.Errorf("can not parse %q as csv: %w", name, err)
+		}
+	default:
+		return nil, fmt.Errorf("can not parse %q: unsupported file extension %q", name, ext)
+	}
+	return c, nil
+}
+
+func (l *Loader) unmarshalCSV(file []byte, c *Catalog) error {
+	r := csv.NewReader(bytes.NewReader(file))
+	r.TrimLeadingSpace = l.CSV.TrimLeadingSpace
+	r.Comment = l.CSV.Comment
+	r.LazyQuotes = l.CSV.LazyQuotes
+	if l.CSV.Comma != 0 {
+		r.Comma = l.CSV.Comma
+	}
+	r.ReuseRecord = true
+
+	record, err := r.Read()
+	if err != nil {
+		return err
+	}
+	cTyp := findColumn("type", record)
+	cTitle := findColumn("title", record)
+	cStatus := findColumn("status", record)
+	cCode := findColumn("code", record)
+	cDetail := findColumn("detail", record)
+	cInstance := findColumn("instance", record)
+	cData := findColumn("data", record)
+	cHeaders := findColumn("headers", record)
+	cRetryAfter := findColumn("retryAfter", record)
+	row := 1
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("line %d: %w", row, err)
+		}
+		e := new(Entry)
+		if cTyp != -1 {
+			e.Type = strings.TrimSpace(record[cTyp])
+		}
+		if cTitle != -1 {
+			e.Title = strings.TrimSpace(record[cTitle])
+		}
+		if cStatus != -1 {
+			status, err := strconv.Atoi(strings.TrimSpace(record[cStatus]))
+			if err != nil {
+				return fmt.Errorf("line %d: can not parse 'status' as integer: %w", row, err)
+			}
+			e.Status = status
+		}
+		if cCode != -1 {
+			if codeStr := strings.TrimSpace(record[cCode]); codeStr != "" {
+				code, err := strconv.Atoi(codeStr)
+				if err != nil {
+					return fmt.Errorf("line %d: can not parse 'code' as integer: %w", row, err)
+				}
+				e.Code = code
+			}
+		}
+		if cDetail != -1 {
+			e.Detail = strings.TrimSpace(record[cDetail])
+		}
+		if cInstance != -1 {
+			e.Instance = strings.TrimSpace(record[cInstance])
+		}
+		if cData != -1 {
+			dataStr := strings.TrimSpace(record[cData])
+			if dataStr != "" {
+				if err := json.Unmarshal([]byte(dataStr), &e.Data); err != nil {
+					return fmt.Errorf("line %d: can not parse 'data' as json: %w", row, err)
+				}
+			}
+		}
+		if cHeaders != -1 {
+			headersStr := strings.TrimSpace(record[cHeaders])
+			if headersStr != "" {
+				if err := json.Unmarshal([]byte(headersStr), &e.Headers); err != nil {
+					return fmt.Errorf("line %d: can not parse 'headers' as json: %w", row, err)
+				}
+			}
+		}
+		if cRetryAfter != -1 {
+			if retryAfterStr := strings.TrimSpace(record[cRetryAfter]); retryAfterStr != "" {
+				retryAfter, err := strconv.Atoi(retryAfterStr)
+				if err != nil {
+					return fmt.Errorf("line %d: can not parse 'retryAfter' as integer: %w", row, err)
+				}
+				e.RetryAfter = retryAfter
+			}
+		}
+		*c = append(*c, e)
+		row++
+	}
+	return nil
+}
+
+func findColumn(name string, record []string) int {
+	for i, column := range record {
+		column = strings.TrimSpace(column)
+		if strings.EqualFold(column, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// readEntry reads a file with a single problem type.
+func readEntry(fsys fs.FS, name string) (*Entry, error) {
+	ext := path.Ext(name)
+	file, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	e := new(Entry)
+	base := path.Base(name)
+	e.Type = base[:len(base)-len(ext)]
+
+	switch ext {
+	case ".json":
+		if err := json.Unmarshal(file, e); err != nil {
+			return nil, fmt.Errorf("can not parse %q as json: %w", name, err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(file, e); err != nil {
+			return nil, fmt.Errorf("can not parse %q as yaml: %w", name, err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(file, e); err != nil {
+			return nil, fmt.Errorf("can not parse %q as toml: %w", name, err)
+		}
+	case ".md":
+		if err := unmarshalFrontMatter(file, e); err != nil {
+			return nil, fmt.Errorf("can not parse %q as markdown: %w", name, err)
+		}
+	default:
+		return nil, fmt.Errorf("can not parse %q: unsupported file extension %q", name, ext)
+	}
+
+	if e.Title == "" {
+		e.Title = http.StatusText(e.Status)
+	}
+
+	return e, nil
+}
+
+// unmarshalFrontMatter parses the json, yaml (between "---") or toml (between "+++") front matter of a markdown file.
+func unmarshalFrontMatter(file []byte, e *Entry) error {
+	jsonHeader := bytes.Index(file, []byte("{"))
+	yamlHeader := bytes.Index(file, []byte("---"))
+	tomlHeader := bytes.Index(file, []byte("+++"))
+	if jsonHeader != -1 && (jsonHeader < tomlHeader || tomlHeader == -1) && (jsonHeader < yamlHeader || yamlHeader == -1) {
+		// the json object ends the front matter, so it is decoded as the first value of the file
+		if err := json.NewDecoder(bytes.NewReader(file[jsonHeader:])).Decode(e); err != nil {
+			return fmt.Errorf("json front matter: %w", err)
+		}
+	} else if yamlHeader != -1 && (yamlHeader < tomlHeader || tomlHeader == -1) {
+		headerCloser := bytes.Index(file[yamlHeader+3:], []byte("---"))
+		if headerCloser == -1 {
+			return errors.New("yaml front matter: missing closing '---'")
+		}
+		if err := yaml.Unmarshal(file[yamlHeader+3:yamlHeader+3+headerCloser], e); err != nil {
+			return fmt.Errorf("yaml front matter: %w", err)
+		}
+	} else if tomlHeader != -1 {
+		headerCloser := bytes.Index(file[tomlHeader+3:], []byte("+++"))
+		if headerCloser == -1 {
+			return errors.New("toml front matter: missing closing '+++'")
+		}
+		if err := toml.Unmarshal(file[tomlHeader+3:tomlHeader+3+headerCloser], e); err != nil {
+			return fmt.Errorf("toml front matter: %w", err)
+		}
+	} else {
+		return errors.New("missing or unsupported front matter")
+	}
+	return nil
+}
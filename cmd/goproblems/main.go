@@ -5,21 +5,18 @@ package main
 
 import (
 	"bytes"
-	"encoding/csv"
-	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
-	"io"
-	"net/http"
+	"go/format"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
-	"github.com/BurntSushi/toml"
-	"gopkg.in/yaml.v2"
+	"github.com/halliday/go-problems/catalog"
 )
 
 const Command = "goproblems"
@@ -52,6 +49,7 @@ var errType *string
 
 var withErrorsMap *bool
 var withStruct *bool
+var withRegister *bool
 
 func main() {
 	i = flag.String("i", defaultInput, "input file")
@@ -70,16 +68,17 @@ func main() {
 
 	withErrorsMap = flag.Bool("with-errors-map", false, "generate errors map")
 	withStruct = flag.Bool("with-struct", false, "generate struct")
+	withRegister = flag.Bool("with-register", false, "generate registration with problems.Register")
 
 	flag.Parse()
 
-	catalog, err := readCatalog(*i)
+	c, err := readCatalog(*i)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Can not read input file: %v", err)
 		os.Exit(1)
 	}
 
-	if err := writeCatalog(catalog, *o); err != nil {
+	if err := writeCatalog(c, *o); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Can not write output file: %v", err)
 		os.Exit(1)
 	}
@@ -128,10 +127,31 @@ func currentCommand() string {
 	if *withStruct {
 		b.WriteString(" -with-struct")
 	}
+	if *withRegister {
+		b.WriteString(" -with-register")
+	}
 
 	return b.String()
 }
 
+// readCatalog loads the catalog at i. Only the last element of i may be a pattern.
+func readCatalog(i string) (catalog.Catalog, error) {
+	dir, pattern := filepath.Split(i)
+	if dir == "" {
+		dir = "."
+	}
+	if pattern == "" {
+		pattern = "."
+	}
+	l := catalog.Loader{CSV: catalog.CSVOptions{
+		TrimLeadingSpace: *csvTrimLeadingSpace,
+		Comment:          firstRune(*csvComment),
+		LazyQuotes:       *csvLazyQuotes,
+		Comma:            firstRune(*csvComma),
+	}}
+	return l.Load(os.DirFS(dir), pattern)
+}
+
 var kebabRegexp = regexp.MustCompile(`(^|-)[a-z]`)
 var snakeRegexp = regexp.MustCompile(`(^|_)[a-z]`)
 
@@ -147,250 +167,8 @@ func kebabToCamel(s string) string {
 	})
 }
 
-type Catalog []*Error
-
-type Error struct {
-	Type     string         `json:"type" yaml:"type" toml:"type"`
-	Title    string         `json:"title" yaml:"title" toml:"title"`
-	Status   int            `json:"status" yaml:"status" toml:"status"`
-	Detail   string         `json:"detail,omitempty" yaml:"detail,omitempty" toml:"detail,omitempty"`
-	Instance string         `json:"instance,omitempty" yaml:"instance,omitempty" toml:"instance,omitempty"`
-	Data     map[string]any `json:"data,omitempty" yaml:"data,omitempty" toml:"data,omitempty"`
-}
-
-func readCatalog(i string) (Catalog, error) {
-	if strings.IndexByte(i, '*') != -1 {
-		return readCatalogFromGlob(i)
-	}
-	info, err := os.Lstat(i)
-	if err != nil {
-		return nil, err
-	}
-	if info.IsDir() {
-		return readCatalogFromDir(i)
-	}
-	return readCatalogFromFile(i)
-}
-
-func readCatalogFromGlob(pattern string) (Catalog, error) {
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, err
-	}
-	c := make(Catalog, 0, len(matches))
-	var errs []error
-	for _, match := range matches {
-		e, err := readErrorFromFile(match)
-		if err != nil {
-			errs = append(errs, err)
-		} else {
-			c = append(c, e)
-		}
-	}
-	return c, errors.Join(errs...)
-}
-
-func readCatalogFromDir(dir string) (Catalog, error) {
-	files, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, err
-	}
-	c := make(Catalog, 0, len(files))
-	var errs []error
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
-		e, err := readErrorFromFile(filepath.Join(dir, file.Name()))
-		if err != nil {
-			errs = append(errs, err)
-		} else {
-			c = append(c, e)
-		}
-	}
-	return c, errors.Join(errs...)
-}
-
-func readCatalogFromFile(name string) (Catalog, error) {
-	ext := filepath.Ext(name)
-	file, err := os.ReadFile(name)
-	if err != nil {
-		return nil, err
-	}
-	switch ext {
-	case ".json":
-		c := make(Catalog, 0, 1)
-		if err := json.Unmarshal(file, &c); err != nil {
-			return nil, fmt.Errorf("can not parse %q as json: %w", name, err)
-		}
-		return c, nil
-	case ".yaml", ".yml":
-		c := make(Catalog, 0, 1)
-		if err := yaml.Unmarshal(file, &c); err != nil {
-			return nil, fmt.Errorf("can not parse %q as yaml: %w", name, err)
-		}
-		return c, nil
-	case ".toml":
-		c := make(Catalog, 0, 1)
-		if err := toml.Unmarshal(file, &c); err != nil {
-			return nil, fmt.Errorf("can not parse %q as toml: %w", name, err)
-		}
-		return c, nil
-	case ".csv":
-		c := make(Catalog, 0, 1)
-		if err := csvUnmarshalCatalog(file, &c); err != nil {
-			return nil, err
-		}
-		return c, nil
-
-	default:
-		return nil, fmt.Errorf("can not parse %q: unsupported file extension %q", name, ext)
-	}
-}
-
-func csvUnmarshalCatalog(file []byte, c *Catalog) error {
-	r := csv.NewReader(bytes.NewBuffer(file))
-	r.TrimLeadingSpace = *csvTrimLeadingSpace
-	r.Comment = rune((*csvComment)[0])
-	r.LazyQuotes = *csvLazyQuotes
-	r.Comma = rune((*csvComma)[0])
-	r.ReuseRecord = true
-
-	record, err := r.Read()
-	if err != nil {
-		return fmt.Errorf("can not parse file as csv: %w", err)
-	}
-	cTyp := findColumn("type", record)
-	cTitle := findColumn("title", record)
-	cStatus := findColumn("status", record)
-	cDetail := findColumn("detail", record)
-	cInstance := findColumn("instance", record)
-	cData := findColumn("data", record)
-	row := 1
-	for {
-		record, err := r.Read()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("can not parse file as csv: line %d: %w", row, err)
-		}
-		e := new(Error)
-		if cTyp != -1 {
-			e.Type = strings.TrimSpace(record[cTyp])
-		}
-		if cTitle != -1 {
-			e.Title = strings.TrimSpace(record[cTitle])
-		}
-		if cStatus != -1 {
-			status, err := strconv.Atoi(strings.TrimSpace(record[cStatus]))
-			if err != nil {
-				return fmt.Errorf("line %d: can not parse 'status' as integer: %w", row, err)
-			}
-			e.Status = status
-		}
-		if cDetail != -1 {
-			e.Detail = strings.TrimSpace(record[cDetail])
-		}
-		if cInstance != -1 {
-			e.Instance = strings.TrimSpace(record[cInstance])
-		}
-		if cData != -1 {
-			dataStr := strings.TrimSpace(record[cData])
-			if dataStr != "" {
-				if err := json.Unmarshal([]byte(dataStr), &e.Data); err != nil {
-					return fmt.Errorf("line %d: can not parse 'data' as json: %w", row, err)
-				}
-			}
-		}
-		*c = append(*c, e)
-		row++
-	}
-	return nil
-}
-
-func findColumn(name string, record []string) int {
-	for i, column := range record {
-		column = strings.TrimSpace(column)
-		if strings.EqualFold(column, name) {
-			return i
-		}
-	}
-	return -1
-}
-
-func readErrorFromFile(name string) (*Error, error) {
-	ext := filepath.Ext(name)
-	file, err := os.ReadFile(name)
-	if err != nil {
-		return nil, err
-	}
-	e := new(Error)
-	base := filepath.Base(name)
-	e.Type = base[:len(base)-len(ext)]
-
-	switch ext {
-	case ".json":
-		if err := json.Unmarshal(file, e); err != nil {
-			return nil, fmt.Errorf("can not parse %q as json: %w", name, err)
-		}
-	case ".yaml", ".yml":
-		if err := yaml.Unmarshal(file, e); err != nil {
-			return nil, fmt.Errorf("can not parse %q as yaml: %w", name, err)
-		}
-	case ".toml":
-		if err := toml.Unmarshal(file, e); err != nil {
-			return nil, fmt.Errorf("can not parse %q as toml: %w", name, err)
-		}
-
-	case ".md":
-		jsonHeader := bytes.Index(file, []byte("{"))
-		yamlHeader := bytes.Index(file, []byte("---"))
-		tomlHeader := bytes.Index(file, []byte("+++"))
-		if jsonHeader != -1 && (jsonHeader < tomlHeader || tomlHeader == -1) && (jsonHeader < yamlHeader || yamlHeader == -1) {
-			headerCloser := bytes.Index(file[jsonHeader+3:], []byte("}"))
-			if headerCloser == -1 {
-				return nil, fmt.Errorf("can not parse %q as markdown with json from matter: missing closing brace '}'", name)
-			}
-			if err := json.Unmarshal(file[jsonHeader+3:headerCloser+3], e); err != nil {
-				return nil, fmt.Errorf("can not parse %q as markdown with json from matter: %w", name, err)
-			}
-		} else if yamlHeader != -1 && (yamlHeader < tomlHeader || tomlHeader == -1) {
-			headerCloser := bytes.Index(file[yamlHeader+3:], []byte("---"))
-			if headerCloser == -1 {
-				return nil, fmt.Errorf("can not parse %q as markdown with yaml from matter: missing closing '---'", name)
-			}
-			if err := yaml.Unmarshal(file[yamlHeader+3:headerCloser+3], e); err != nil {
-				return nil, fmt.Errorf("can not parse %q as markdown with yaml from matter: %w", name, err)
-			}
-		} else if tomlHeader != -1 {
-			headerCloser := bytes.Index(file[tomlHeader+3:], []byte("+++"))
-			if headerCloser == -1 {
-				return nil, fmt.Errorf("can not parse %q as markdown with toml from matter: missing closing '+++'", name)
-			}
-			if err := toml.Unmarshal(file[tomlHeader+3:headerCloser+3], e); err != nil {
-				return nil, fmt.Errorf("can not parse %q as markdown with toml from matter: %w", name, err)
-			}
-		} else {
-			return nil, fmt.Errorf("can not parse %q as markdown: missing or unsupported front matter", name)
-		}
-	default:
-		return nil, fmt.Errorf("can not parse %q: unsupported file extension %q", name, ext)
-	}
-
-	if e.Title == "" {
-		e.Title = http.StatusText(e.Status)
-	}
-
-	return e, nil
-}
-
-func writeCatalog(catalog Catalog, o string) error {
-	output, err := os.Create(o)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Can not create output file.\n%s\n", err)
-		os.Exit(1)
-	}
+func writeCatalog(c catalog.Catalog, o string) error {
+	output := new(bytes.Buffer)
 
 	var casingToCamel func(string) string
 
@@ -411,38 +189,49 @@ func writeCatalog(catalog Catalog, o string) error {
 	output.WriteString("\n\n")
 	output.WriteString("package " + *p + "\n\n")
 	// output.WriteString("import \"fmt\"\n\n")
+	var imports []string
+	for _, p := range c {
+		if p.Header() != nil {
+			imports = append(imports, "net/http")
+			break
+		}
+	}
+	if *withStruct || *withRegister {
+		if len(imports) != 0 {
+			imports = append(imports, "")
+		}
+		imports = append(imports, "github.com/halliday/go-problems")
+	}
+	switch len(imports) {
+	case 0:
+	case 1:
+		output.WriteString("import \"" + imports[0] + "\"\n\n")
+	default:
+		output.WriteString("import (\n")
+		for _, i := range imports {
+			if i != "" {
+				output.WriteString("\t\"" + i + "\"")
+			}
+			output.WriteString("\n")
+		}
+		output.WriteString(")\n\n")
+	}
 	if *withStruct {
-		output.WriteString("// " + *errType + " is the generic error type for this package.\ntype " + *errType + ` struct {
-	Type     string
-	Status   int
-	Title    string
-	Detail   string
-	Instance string
-	Data     map[string]any
-	Wraps    error
-}
-
-// Error implements the error interface.
-func (e ` + *errType + `) Error() string {
-	return e.Detail
-}
-
-// Unwrap implements the errors.Unwrap function.
-func (e ` + *errType + `) Unwrap() error {
-	return e.Wraps
-}
-
-// Problem implements the Problem interface.
-func (e ` + *errType + `) Problem() (typ string, title string, status int, detail string, instance string, data map[string]any) {
-	return e.Type, e.Title, e.Status, e.Detail, e.Instance, e.Data
-}
-`)
+		output.WriteString("// " + *errType + " is the generic error type for this package.\ntype " + *errType + " = problems.Error\n")
 	}
 
-	for _, p := range catalog {
+	for _, p := range c {
 		variable := *errPrefix + casingToCamel(p.Type)
-		output.WriteString("\n// " + variable + " means: \"" + p.Title + "\" Type: \"" + p.Type + "\", Status: " + strconv.Itoa(p.Status) + "\n")
-		output.WriteString("var " + variable + " = &" + *errType + "{Type: \"" + p.Type + "\", Status: " + strconv.Itoa(p.Status) + ", Title: \"" + p.Title + "\"")
+		output.WriteString("\n// " + variable + " means: \"" + p.Title + "\" Type: \"" + p.Type + "\", Status: " + strconv.Itoa(p.Status))
+		if p.Code != 0 {
+			output.WriteString(", Code: " + strconv.Itoa(p.Code))
+		}
+		output.WriteString("\n")
+		output.WriteString("var " + variable + " = &" + *errType + "{Type: \"" + p.Type + "\", Status: " + strconv.Itoa(p.Status))
+		if p.Code != 0 {
+			output.WriteString(", Code: " + strconv.Itoa(p.Code))
+		}
+		output.WriteString(", Title: \"" + p.Title + "\"")
 		if p.Detail != "" {
 			output.WriteString(", Detail: \"" + p.Detail + "\"")
 		}
@@ -455,24 +244,54 @@ func (e ` + *errType + `) Problem() (typ string, title string, status int, detai
 				fmt.Fprintf(os.Stderr, "Error: Can not marshal data for %q.\n%s\n", p.Type, err)
 				os.Exit(1)
 			}
-			output.WriteString(", Data: \"" + string(data) + "\"")
+			output.WriteString(", Data: " + string(data))
+		}
+		if h := p.Header(); h != nil {
+			output.WriteString(", Header: http.Header{")
+			keys := make([]string, 0, len(h))
+			for k := range h {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for i, k := range keys {
+				if i != 0 {
+					output.WriteString(", ")
+				}
+				output.WriteString(strconv.Quote(k) + ": {" + strconv.Quote(h.Get(k)) + "}")
+			}
+			output.WriteString("}")
 		}
 		output.WriteString("}\n")
 	}
 
 	if *withErrorsMap {
 		output.WriteString("\nvar Errors = map[string]*" + *errType + "{")
-		for _, p := range catalog {
+		for _, p := range c {
 			variable := *errPrefix + casingToCamel(p.Type)
 			output.WriteString("\n\t\"" + p.Type + "\": " + variable + ",")
 		}
-		if len(catalog) > 0 {
+		if len(c) > 0 {
 			output.WriteString("\n")
 		}
 		output.WriteString("}\n")
 	}
 
-	return nil
+	if *withRegister {
+		output.WriteString("\nfunc init() {\n\tproblems.Register(")
+		for _, p := range c {
+			output.WriteString("\n\t\t" + *errPrefix + casingToCamel(p.Type) + ",")
+		}
+		if len(c) > 0 {
+			output.WriteString("\n\t")
+		}
+		output.WriteString(")\n}\n")
+	}
+
+	src, err := format.Source(output.Bytes())
+	if err != nil {
+		return fmt.Errorf("can not format generated code: %w", err)
+	}
+	return os.WriteFile(o, src, 0666)
 }
 
 func marshalLiteral(v interface{}) ([]byte, error) {
@@ -491,6 +310,8 @@ func writeLiteral(b *bytes.Buffer, v interface{}) error {
 		} else {
 			b.WriteString("false")
 		}
+	case int:
+		b.WriteString(strconv.Itoa(v))
 	case int64:
 		b.WriteString(strconv.FormatInt(v, 10))
 	case float64:
@@ -528,3 +349,12 @@ func writeLiteral(b *bytes.Buffer, v interface{}) error {
 	}
 	return nil
 }
+
+// firstRune returns the first rune of s, or 0 if s is empty.
+func firstRune(s string) rune {
+	if s == "" {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
@@ -0,0 +1,16 @@
+// Command problemsvet checks calls of problems.Pprintf and its wrappers.
+// It is run by go vet:
+//
+//	go install github.com/halliday/go-problems/cmd/problemsvet
+//	go vet -vettool=$(which problemsvet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	"github.com/halliday/go-problems/analysis/pprintf"
+)
+
+func main() {
+	unitchecker.Main(pprintf.Analyzer)
+}
@@ -0,0 +1,90 @@
+package problems
+
+import "fmt"
+
+// CollisionPolicy decides what happens to extension members that have
+// the name of a standard member, like "status" or "type".
+type CollisionPolicy int
+
+const (
+	// PrefixCollisions renames colliding members by prepending CollisionPrefix.
+	PrefixCollisions CollisionPolicy = iota
+	// NestCollisions moves colliding members into an object under CollisionKey.
+	NestCollisions
+	// RejectCollisions refuses to marshal the problem.
+	// ServeProblem serves ErrInternalServerError instead.
+	RejectCollisions
+)
+
+// Collisions is the policy for extension members that collide with standard members.
+// Every collision is reported to Logger.
+var Collisions = PrefixCollisions
+
+// CollisionPrefix is prepended to colliding members by PrefixCollisions.
+var CollisionPrefix = "_"
+
+// CollisionKey is the member that holds colliding members with NestCollisions.
+var CollisionKey = "data"
+
+// reserved are the standard members of RFC 7807.
+var reserved = map[string]bool{
+	"type":     true,
+	"title":    true,
+	"status":   true,
+	"detail":   true,
+	"instance": true,
+}
+
+// resolveCollisions applies the Collisions policy to the extension members of the problem typ.
+// It returns data unchanged if there are no collisions.
+func resolveCollisions(typ string, data map[string]any) (map[string]any, error) {
+	var collisions []string
+	for k := range data {
+		if reserved[k] {
+			collisions = append(collisions, k)
+		}
+	}
+	if len(collisions) == 0 {
+		return data, nil
+	}
+	for _, k := range collisions {
+		logger().Warn("problem: extension member collides with a standard member", "type", typ, "member", k)
+	}
+	if Collisions == RejectCollisions {
+		return nil, fmt.Errorf("type %q: extension members %q collide with standard members", typ, collisions)
+	}
+
+	m := make(map[string]any, len(data))
+	for k, v := range data {
+		if !reserved[k] {
+			m[k] = v
+		}
+	}
+	switch Collisions {
+	case NestCollisions:
+		nested := make(map[string]any, len(collisions))
+		for _, k := range collisions {
+			nested[k] = data[k]
+		}
+		m[unusedKey(m, CollisionKey)] = nested
+	default:
+		for _, k := range collisions {
+			m[unusedKey(m, CollisionPrefix+k)] = data[k]
+		}
+	}
+	return m, nil
+}
+
+// unusedKey prepends CollisionPrefix to k until it is not a member of m.
+func unusedKey(m map[string]any, k string) string {
+	prefix := CollisionPrefix
+	if prefix == "" {
+		prefix = "_"
+	}
+	for {
+		if _, ok := m[k]; !ok && !reserved[k] {
+			return k
+		}
+		k = prefix + k
+	}
+}
@@ -0,0 +1,80 @@
+package problems
+
+import (
+	"mime"
+	"net/http"
+)
+
+// StatusProblem returns the problem that Convert serves for an error status code.
+// Replace it to serve more specific problems, like ErrNotFound for 404.
+var StatusProblem = func(status int) Problem {
+	return &Error{Type: "about:blank", Status: status, Title: http.StatusText(status)}
+}
+
+// Convert returns a handler that converts the error responses of h into problems.
+// When h writes a status code of 400 or above without a problem content type,
+// like http.Error, http.NotFound, http.TimeoutHandler and http.ServeMux do,
+// the body written by h is discarded and the problem of StatusProblem is served with Serve instead.
+// Headers set by h, like Allow, are kept.
+// Problems that h serves with this package are passed on in any format.
+func Convert(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		h.ServeHTTP(&convertWriter{headerWriter: headerWriter{ResponseWriter: resp}, req: req}, req)
+	})
+}
+
+// convertWriter replaces non-problem error responses with problems.
+type convertWriter struct {
+	headerWriter
+	req       *http.Request
+	serving   bool // a problem is being served by this package, in any format
+	converted bool // the body of h is discarded
+}
+
+func (w *convertWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader || w.serving || statusCode < 400 || isProblemContentType(w.Header().Get("Content-Type")) {
+		w.headerWriter.WriteHeader(statusCode)
+		return
+	}
+	w.wroteHeader = true
+	w.converted = true
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Del("Content-Type")
+	h.Del("Content-Encoding")
+	Serve(w.ResponseWriter, w.req, StatusProblem(statusCode))
+}
+
+func (w *convertWriter) Write(b []byte) (int, error) {
+	if w.converted {
+		return len(b), nil
+	}
+	return w.headerWriter.Write(b)
+}
+
+func (w *convertWriter) Flush() {
+	if !w.converted {
+		w.headerWriter.Flush()
+	}
+}
+
+// markServing tells the convert writers in the chain of resp that a problem is being served,
+// so that they pass on problems in formats like html and plain text.
+func markServing(resp http.ResponseWriter) {
+	for {
+		switch w := resp.(type) {
+		case *convertWriter:
+			w.serving = true
+			resp = w.ResponseWriter
+		case interface{ Unwrap() http.ResponseWriter }:
+			resp = w.Unwrap()
+		default:
+			return
+		}
+	}
+}
+
+func isProblemContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == ContentType || mediaType == XMLContentType)
+}
@@ -0,0 +1,134 @@
+package problems
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// ContentType is the media type of problem documents encoded as json.
+const ContentType = "application/problem+json"
+
+// DecodeResponse reads a problem document from the body of resp.
+// The response must have the application/problem+json content type.
+// If the document has no status member, the response status code is used.
+// Use DecodeResponseProblem to get the registered Go type of the problem instead.
+func DecodeResponse(resp *http.Response) (*Error, error) {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("problem: can not parse content type: %w", err)
+	}
+	if mediaType != ContentType {
+		return nil, fmt.Errorf("problem: unexpected content type %q", mediaType)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("problem: can not read response body: %w", err)
+	}
+	e, err := Decode(body)
+	if err != nil {
+		return nil, err
+	}
+	if e.Status == 0 {
+		e.Status = resp.StatusCode
+	}
+	return e, nil
+}
+
+// Decode parses a json problem document.
+// Use DecodeProblem to get the registered Go type of the problem instead.
+func Decode(data []byte) (*Error, error) {
+	e := new(Error)
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("problem: can not parse problem as json: %w", err)
+	}
+	return e, nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Members that are not part of RFC 7807 are stored in Data, except for an integer code.
+// The "errors" member of a Composite is kept as decoded json, see Error.Problems.
+// Standard members with a wrong json type are ignored, as the RFC requires.
+func (e *Error) UnmarshalJSON(b []byte) error {
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	*e = Error{Type: "about:blank"}
+	for k, v := range m {
+		switch k {
+		case "type":
+			if s, ok := v.(string); ok {
+				e.Type = s
+			}
+		case "title":
+			if s, ok := v.(string); ok {
+				e.Title = s
+			}
+		case "status":
+			if f, ok := v.(float64); ok && f == float64(int(f)) {
+				e.Status = int(f)
+			}
+		case "code":
+			if f, ok := v.(float64); ok && f == float64(int(f)) {
+				e.Code = int(f)
+			} else {
+				e.setData(k, v)
+			}
+		case "detail":
+			if s, ok := v.(string); ok {
+				e.Detail = s
+			}
+		case "instance":
+			if s, ok := v.(string); ok {
+				e.Instance = s
+			}
+		default:
+			e.setData(k, v)
+		}
+	}
+	return nil
+}
+
+// decodeProblems converts the "errors" member of a decoded Composite to problems.
+// It returns nil if the member is not a list of objects with a type or status member.
+func decodeProblems(v any) []Problem {
+	if problems, ok := v.([]Problem); ok {
+		return problems
+	}
+	list, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	problems := make([]Problem, len(list))
+	for i, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil
+		}
+		_, hasType := m["type"]
+		_, hasStatus := m["status"]
+		if !hasType && !hasStatus {
+			return nil
+		}
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil
+		}
+		e := new(Error)
+		if err := json.Unmarshal(b, e); err != nil {
+			return nil
+		}
+		problems[i] = e
+	}
+	return problems
+}
+
+func (e *Error) setData(k string, v any) {
+	if e.Data == nil {
+		e.Data = make(map[string]any)
+	}
+	e.Data[k] = v
+}
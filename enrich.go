@@ -0,0 +1,163 @@
+package problems
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Enricher adds request specific information to a problem before it is served.
+// e is a copy of the served problem with its own Data and Header, that the enricher may modify.
+type Enricher func(req *http.Request, e *Error)
+
+// Enrichers are applied in order to every problem served by Serve and the handlers of this package.
+// ServeProblem has no request and does not apply them.
+//
+//	problems.Enrichers = []problems.Enricher{
+//		problems.RequestID("X-Request-Id"),
+//		problems.TraceID,
+//		problems.BaseURI("https://example.com/problems/"),
+//	}
+var Enrichers []Enricher
+
+// enrich returns a copy of p with the Enrichers applied, or p itself if there is nothing to apply.
+// Composites stay composites, with the Enrichers applied to each of their problems too.
+func enrich(req *http.Request, p Problem) Problem {
+	if req == nil || len(Enrichers) == 0 {
+		return p
+	}
+	if c, ok := p.(*Composite); ok {
+		return c.enrich(req)
+	}
+	e := toError(p)
+	for _, enricher := range Enrichers {
+		enricher(req, e)
+	}
+	return e
+}
+
+// toError returns a copy of p as an Error, with the headers of p and the data copied.
+func toError(p Problem) *Error {
+	var e Error
+	switch p := p.(type) {
+	case *Error:
+		e = *p
+	case Error:
+		e = p
+	default:
+		e.Type, e.Title, e.Status, e.Detail, e.Instance, e.Data = p.Problem()
+		e.Wraps, _ = p.(error)
+	}
+	e.Header = make(http.Header)
+	setHeader(e.Header, p)
+	data := make(map[string]any, len(e.Data)+2)
+	for k, v := range e.Data {
+		data[k] = v
+	}
+	e.Data = data
+	return &e
+}
+
+// RequestID returns an Enricher that sets the instance of problems without one
+// to the request ID in the given request header.
+// Requests without the header get a random "urn:uuid:" instance,
+// so that clients can still refer to the occurrence in the logs.
+func RequestID(header string) Enricher {
+	return func(req *http.Request, e *Error) {
+		if e.Instance != "" {
+			return
+		}
+		if id := req.Header.Get(header); id != "" {
+			e.Instance = id
+		} else {
+			e.Instance = "urn:uuid:" + newUUID()
+		}
+	}
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// TraceID is an Enricher that adds the trace ID of the W3C traceparent header
+// of the request as the "traceId" member.
+func TraceID(req *http.Request, e *Error) {
+	if _, ok := e.Data["traceId"]; ok {
+		return
+	}
+	if id, ok := parseTraceparent(req.Header.Get("traceparent")); ok {
+		e.Data["traceId"] = id
+	}
+}
+
+// parseTraceparent returns the trace ID of a traceparent header,
+// formatted as version "-" trace-id "-" parent-id "-" trace-flags.
+func parseTraceparent(s string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", false
+	}
+	id := parts[1]
+	if len(id) != 32 || !isLowerHex(id) || id == strings.Repeat("0", 32) {
+		return "", false
+	}
+	if len(parts[2]) != 16 || !isLowerHex(parts[2]) || len(parts[3]) != 2 || !isLowerHex(parts[3]) {
+		return "", false
+	}
+	return id, true
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Timestamp is an Enricher that adds the time of the occurrence as the "timestamp" member,
+// formatted as RFC 3339 in UTC.
+func Timestamp(req *http.Request, e *Error) {
+	if _, ok := e.Data["timestamp"]; !ok {
+		e.Data["timestamp"] = time.Now().UTC().Format(time.RFC3339)
+	}
+}
+
+// BaseURI returns an Enricher that resolves relative problem types against base,
+// so that types like "out-of-credits" are served as absolute URIs.
+// It panics if base is not a valid URI.
+func BaseURI(base string) Enricher {
+	u, err := url.Parse(base)
+	if err != nil {
+		panic("problem: can not parse base URI: " + err.Error())
+	}
+	return func(req *http.Request, e *Error) {
+		if e.Type == "" || e.Type == "about:blank" {
+			return
+		}
+		ref, err := url.Parse(e.Type)
+		if err != nil || ref.IsAbs() {
+			return
+		}
+		e.Type = u.ResolveReference(ref).String()
+	}
+}
@@ -0,0 +1,139 @@
+package problems
+
+import "net/http"
+
+// Error is a problem details object that can be used as a Go error.
+// It is the type of the variables generated by cmd/goproblems.
+type Error struct {
+	Type     string
+	Status   int
+	Code     int // application specific code, served as the "code" member
+	Title    string
+	Detail   string
+	Instance string
+	Data     map[string]any
+	Header   http.Header // added to the http response
+	Wraps    error
+}
+
+// Error implements the error interface.
+func (e Error) Error() string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+	return e.Title
+}
+
+// Unwrap implements the errors.Unwrap function.
+func (e Error) Unwrap() error {
+	return e.Wraps
+}
+
+// Is reports whether target is an Error with the same type.
+// An empty type is the same as "about:blank", whose problems are only the same if their status is.
+func (e Error) Is(target error) bool {
+	switch t := target.(type) {
+	case *Error:
+		return t != nil && e.sameType(*t)
+	case Error:
+		return e.sameType(t)
+	}
+	return false
+}
+
+func (e Error) sameType(t Error) bool {
+	typ, ttyp := e.Type, t.Type
+	if typ == "" {
+		typ = "about:blank"
+	}
+	if ttyp == "" {
+		ttyp = "about:blank"
+	}
+	return typ == ttyp && (typ != "about:blank" || e.Status == t.Status)
+}
+
+// Problem implements the Problem interface.
+func (e Error) Problem() (typ string, title string, status int, detail string, instance string, data map[string]any) {
+	return e.Type, e.Title, e.Status, e.Detail, e.Instance, e.data()
+}
+
+// data returns the extension members of e, including the code.
+func (e Error) data() map[string]any {
+	if e.Code == 0 {
+		return e.Data
+	}
+	return withMember(e.Data, "code", e.Code)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (e Error) MarshalJSON() ([]byte, error) {
+	typ := e.Type
+	if typ == "" {
+		typ = "about:blank"
+	}
+	data, err := resolveCollisions(typ, e.data())
+	if err != nil {
+		return nil, err
+	}
+	return problem{
+		typ:      typ,
+		title:    e.Title,
+		status:   e.Status,
+		detail:   e.Detail,
+		instance: e.Instance,
+		data:     data,
+	}.MarshalJSON()
+}
+
+// WithDetail returns a copy of e with the given detail.
+func (e Error) WithDetail(detail string) *Error {
+	e.Detail = detail
+	return &e
+}
+
+// Wrap returns a copy of e that wraps err.
+func (e Error) Wrap(err error) *Error {
+	e.Wraps = err
+	return &e
+}
+
+// Errorf returns a copy of e with the detail, data and wrapped error built by Pprintf.
+// The data is merged with the data of e.
+func (e Error) Errorf(format string, args ...any) *Error {
+	detail, data, wraps := Pprintf(format, args...)
+	return e.with(detail, data, wraps)
+}
+
+// Namedf returns a copy of e with the detail, data and wrapped error built by Pnamedf.
+// The data is merged with the data of e.
+// If the template or the args are malformed, the error is reported to Logger
+// and the template is used as detail.
+func (e Error) Namedf(template string, args ...any) *Error {
+	detail, data, wraps, err := Pnamedf(template, args...)
+	if err != nil {
+		logger().Warn("problem: can not format detail", "type", e.Type, "error", err)
+		detail = template
+	}
+	return e.with(detail, data, wraps)
+}
+
+func (e Error) with(detail string, data map[string]any, wraps error) *Error {
+	e.Detail = detail
+	if wraps != nil {
+		e.Wraps = wraps
+	}
+	if len(e.Data) != 0 && len(data) != 0 {
+		merged := make(map[string]any, len(e.Data)+len(data))
+		for k, v := range e.Data {
+			merged[k] = v
+		}
+		for k, v := range data {
+			merged[k] = v
+		}
+		data = merged
+	} else if len(data) == 0 {
+		data = e.Data
+	}
+	e.Data = data
+	return &e
+}
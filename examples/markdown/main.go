@@ -3,11 +3,8 @@ package main
 import (
 	"context"
 	"flag"
-	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/halliday/go-problems"
 )
@@ -15,8 +12,16 @@ import (
 func main() {
 	addr := flag.String("addr", ":8080", "address to listen on")
 
+	problems.ErrInternalServerError = ErrInternalServerError
+	problems.Enrichers = []problems.Enricher{
+		problems.RequestID("X-Request-Id"),
+		problems.TraceID,
+		problems.Timestamp,
+		problems.BaseURI(ProblemsLocation),
+	}
+
 	http.Handle("/", http.HandlerFunc(index))
-	http.Handle("/api/withdraw", http.HandlerFunc(postWithdraw))
+	http.Handle("/api/withdraw", problems.Recover(problems.HandlerFunc(postWithdraw)))
 
 	log.Printf("Listening on %s...", *addr)
 
@@ -27,35 +32,27 @@ func index(resp http.ResponseWriter, req *http.Request) {
 	http.ServeFile(resp, req, "index.html")
 }
 
-func postWithdraw(resp http.ResponseWriter, req *http.Request) {
+func postWithdraw(resp http.ResponseWriter, req *http.Request) error {
 	if req.Method != http.MethodPost {
-		serveErrorf(resp, req, ErrMethodNotAllowed, "Use POST instead of %s.", req.Method)
-		return
+		return ErrMethodNotAllowed.Errorf("Use POST instead of %s.", req.Method)
 	}
 
-	body, err := io.ReadAll(req.Body)
-	if err != nil {
-		serveErrorf(resp, req, ErrBadRequest, "Cannot read request body.", err)
-		return
+	var body struct {
+		Amount float64 `json:"amount"`
 	}
-	amount, err := strconv.ParseFloat(string(body), 64)
-	if err != nil {
-		serveErrorf(resp, req, ErrBadRequest, "Cannot parse amount.", err)
-		return
+	if err := problems.DecodeJSON(resp, req, &body); err != nil {
+		return err
 	}
 
-	if err := withdraw(req.Context(), amount); err != nil {
-		serveError(resp, req, err)
-		return
-	}
+	return withdraw(req.Context(), body.Amount)
 }
 
 func withdraw(ctx context.Context, amount float64) error {
 	if amount <= 0 {
-		return errorf(ErrBadRequest, "The amount to withdraw must be a positive number.", "requestedAmount", amount, "availableAmount", 4200)
+		return ErrBadRequest.Errorf("The amount to withdraw must be a positive number.", "requestedAmount", amount, "availableAmount", 4200)
 	}
 	if amount > 4200 {
-		return errorf(ErrOutOfCredits, "Cannot withdraw %.2f when you have only 4200.00 available.", amount, "requestedAmount", amount, "availableAmount", 4200)
+		return ErrOutOfCredits.Namedf("Cannot withdraw {requestedAmount:%.2f} when you have only {availableAmount:%.2f} available.", "requestedAmount", amount, "availableAmount", 4200.0)
 	}
 	return nil
 }
@@ -69,24 +66,3 @@ var _ problems.Problem = (*Error)(nil)
 var _ error = (*Error)(nil)
 
 const ProblemsLocation = "http://localhost/problems/"
-
-func serveErrorf(resp http.ResponseWriter, req *http.Request, err *Error, format string, args ...any) {
-	serveError(resp, req, errorf(err, format, args...))
-}
-
-func serveError(resp http.ResponseWriter, req *http.Request, err error) {
-	if e, ok := err.(*Error); ok {
-		p := *e // copy
-		p.Instance = req.RequestURI
-		p.Type = fmt.Sprintf("%s%d-%s", ProblemsLocation, e.Status, e.Type)
-		problems.ServeProblem(resp, &p)
-	} else {
-		log.Printf("error: %v", err)
-		serveError(resp, req, ErrInternalServerError)
-	}
-}
-
-func errorf(err *Error, format string, args ...any) *Error {
-	detail, data, wraps := problems.Pprintf(format, args...)
-	return &Error{err.Type, err.Status, err.Title, detail, err.Instance, data, wraps}
-}
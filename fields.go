@@ -0,0 +1,170 @@
+package problems
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// field is a struct field that becomes a data member.
+type field struct {
+	name      string
+	tagged    bool  // name comes from a json tag
+	index     []int // for reflect.Value.Field, through embedded structs
+	omitEmpty bool
+	typ       reflect.Type
+}
+
+// fieldCache maps struct types to their []field.
+var fieldCache sync.Map
+
+// structData converts a struct to data, following the rules of encoding/json:
+// fields are renamed and omitted by their json tags, and the fields of embedded structs are flattened.
+func structData(v reflect.Value) map[string]any {
+	fields := cachedFields(v.Type())
+	m := make(map[string]any, len(fields))
+FIELDS:
+	for _, f := range fields {
+		fv := v
+		for _, i := range f.index {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue FIELDS
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(i)
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		m[f.name] = fv.Interface()
+	}
+	return m
+}
+
+func cachedFields(t reflect.Type) []field {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]field)
+	}
+	fields, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return fields.([]field)
+}
+
+// typeFields returns the fields of t, like encoding/json does:
+// embedded structs are searched breadth first, and of the fields with the same name
+// only the shallowest one is kept. If there is more than one at that depth,
+// a single tagged one is kept, otherwise all of them are dropped.
+func typeFields(t reflect.Type) []field {
+	var fields []field
+	next := []field{{typ: t}}
+	visited := make(map[reflect.Type]bool)
+	for len(next) > 0 {
+		current := next
+		next = nil
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+			visited[f.typ] = true
+			for i := 0; i < f.typ.NumField(); i++ {
+				sf := f.typ.Field(i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := make([]int, len(f.index)+1)
+				copy(index, f.index)
+				index[len(f.index)] = i
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, field{index: index, typ: ft})
+					continue
+				}
+				if name == "" && !sf.IsExported() {
+					continue
+				}
+				fields = append(fields, field{
+					name:      name,
+					tagged:    name != "",
+					index:     index,
+					omitEmpty: hasOption(opts, "omitempty"),
+					typ:       ft,
+				})
+				if name == "" {
+					fields[len(fields)-1].name = sf.Name
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].index) != len(fields[j].index) {
+			return len(fields[i].index) < len(fields[j].index)
+		}
+		return fields[i].tagged && !fields[j].tagged
+	})
+
+	dominant := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if f, ok := dominantField(fields[i:j]); ok {
+			dominant = append(dominant, f)
+		}
+		i = j
+	}
+	return dominant
+}
+
+// dominantField returns the field that wins among fields with the same name,
+// sorted by depth and tagged first.
+func dominantField(fields []field) (field, bool) {
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) && fields[0].tagged == fields[1].tagged {
+		return field{}, false
+	}
+	return fields[0], true
+}
+
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package problems
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// XMLContentType is the media type of problem documents encoded as xml.
+const XMLContentType = "application/problem+xml"
+
+// XMLNamespace is the namespace of problem documents encoded as xml.
+const XMLNamespace = "urn:ietf:rfc:7807"
+
+type format struct {
+	name        string
+	contentType string
+	mediaTypes  []string // media types that select this format, in order of preference
+	write       func(b *bytes.Buffer, p problem) error
+}
+
+var jsonFormat = &format{
+	name:        "json",
+	contentType: ContentType,
+	mediaTypes:  []string{ContentType, "application/json"},
+	write:       writeJSON,
+}
+
+var xmlFormat = &format{
+	name:        "xml",
+	contentType: XMLContentType,
+	mediaTypes:  []string{XMLContentType, "application/xml"},
+	write:       writeXML,
+}
+
+var textFormat = &format{
+	name:        "text",
+	contentType: "text/plain",
+	mediaTypes:  []string{"text/plain"},
+	write:       writeText,
+}
+
+// formats is the list of formats available for content negotiation.
+// The first format is the default.
+var formats = []*format{jsonFormat, xmlFormat, htmlFormat, textFormat}
+
+func writeJSON(b *bytes.Buffer, p problem) error {
+	encoder := json.NewEncoder(b)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+// writeXML writes p in the format from RFC 7807 Appendix A.
+// Objects become nested elements and arrays become sequences of <i> elements.
+func writeXML(b *bytes.Buffer, p problem) error {
+	b.WriteString(xml.Header)
+	encoder := xml.NewEncoder(b)
+	encoder.Indent("", "  ")
+
+	root := xml.StartElement{
+		Name: xml.Name{Local: "problem"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: XMLNamespace}},
+	}
+	if err := encoder.EncodeToken(root); err != nil {
+		return err
+	}
+	if err := encodeXMLElement(encoder, "type", p.typ); err != nil {
+		return err
+	}
+	if err := encodeXMLElement(encoder, "title", p.title); err != nil {
+		return err
+	}
+	if err := encodeXMLElement(encoder, "status", json.Number(strconv.Itoa(p.status))); err != nil {
+		return err
+	}
+	if p.detail != "" {
+		if err := encodeXMLElement(encoder, "detail", p.detail); err != nil {
+			return err
+		}
+	}
+	if p.instance != "" {
+		if err := encodeXMLElement(encoder, "instance", p.instance); err != nil {
+			return err
+		}
+	}
+	if len(p.data) != 0 {
+		data, err := normalize(p.data)
+		if err != nil {
+			return err
+		}
+		if err := encodeXMLMembers(encoder, data.(map[string]any)); err != nil {
+			return err
+		}
+	}
+	if err := encoder.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+	b.WriteByte('\n')
+	return nil
+}
+
+var xmlNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+func encodeXMLMembers(encoder *xml.Encoder, m map[string]any) error {
+	for _, k := range sortedKeys(m) {
+		if !xmlNameRegexp.MatchString(k) || strings.HasPrefix(strings.ToLower(k), "xml") {
+			logger().Warn("problem: can not marshal member as xml: invalid element name", "member", k)
+			continue
+		}
+		if err := encodeXMLElement(encoder, k, m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeXMLElement encodes a value as returned by normalize.
+func encodeXMLElement(encoder *xml.Encoder, name string, v any) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+	case string:
+		if err := encoder.EncodeToken(xml.CharData(v)); err != nil {
+			return err
+		}
+	case json.Number:
+		if err := encoder.EncodeToken(xml.CharData(v)); err != nil {
+			return err
+		}
+	case bool:
+		if err := encoder.EncodeToken(xml.CharData(strconv.FormatBool(v))); err != nil {
+			return err
+		}
+	case []any:
+		for _, v := range v {
+			if err := encodeXMLElement(encoder, "i", v); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		if err := encodeXMLMembers(encoder, v); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// writeText writes p as human readable plain text.
+func writeText(b *bytes.Buffer, p problem) error {
+	b.WriteString(p.title)
+	b.WriteByte('\n')
+	if p.detail != "" {
+		b.WriteString(p.detail)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	b.WriteString("type: " + p.typ + "\n")
+	b.WriteString("status: " + strconv.Itoa(p.status) + "\n")
+	if p.instance != "" {
+		b.WriteString("instance: " + p.instance + "\n")
+	}
+	for _, k := range sortedKeys(p.data) {
+		b.WriteString(k + ": ")
+		if s, ok := p.data[k].(string); ok {
+			b.WriteString(s)
+		} else {
+			v, err := json.Marshal(p.data[k])
+			if err != nil {
+				return err
+			}
+			b.Write(v)
+		}
+		b.WriteByte('\n')
+	}
+	return nil
+}
+
+// normalize converts v to the generic types used by encoding/json,
+// with numbers as json.Number.
+func normalize(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var n any
+	err = decoder.Decode(&n)
+	return n, err
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,8 +1,15 @@
 module github.com/halliday/go-problems
 
-go 1.20
+go 1.22.0
 
 require (
 	github.com/BurntSushi/toml v1.3.2
 	gopkg.in/yaml.v2 v2.4.0
 )
+
+require golang.org/x/tools v0.26.0
+
+require (
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+)
@@ -0,0 +1,32 @@
+package problems
+
+import (
+	"net/http"
+)
+
+// ErrInternalServerError is served for errors that do not contain a Problem.
+// Replace it to change the problem that clients see for unexpected errors.
+var ErrInternalServerError Problem = &Error{Type: "about:blank", Status: http.StatusInternalServerError, Title: "Internal Server Error"}
+
+// HandlerFunc is an http handler that can fail with an error.
+// Errors are served with ServeError.
+type HandlerFunc func(http.ResponseWriter, *http.Request) error
+
+// ServeHTTP implements the http.Handler interface.
+func (f HandlerFunc) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if err := f(resp, req); err != nil {
+		ServeError(resp, req, err)
+	}
+}
+
+// ServeError serves the Problem returned by MapError for err.
+// If there is none, err is logged and ErrInternalServerError is served instead,
+// so that internal error messages do not leak to clients.
+func ServeError(resp http.ResponseWriter, req *http.Request, err error) {
+	p := MapError(err)
+	if p == nil {
+		logger().ErrorContext(req.Context(), "problem: unhandled error", "method", req.Method, "path", req.URL.Path, "error", err)
+		p = ErrInternalServerError
+	}
+	Serve(resp, req, p)
+}
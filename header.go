@@ -0,0 +1,42 @@
+package problems
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HeaderProblem is implemented by problems that add http response headers,
+// like WWW-Authenticate or Allow.
+type HeaderProblem interface {
+	Problem
+	ProblemHeader() http.Header
+}
+
+// RetryAfterProblem is implemented by problems that tell the client when to retry,
+// like 429 Too Many Requests or 503 Service Unavailable.
+// A positive duration is served as the Retry-After header in seconds.
+type RetryAfterProblem interface {
+	Problem
+	RetryAfter() time.Duration
+}
+
+// ProblemHeader implements the HeaderProblem interface.
+func (e Error) ProblemHeader() http.Header {
+	return e.Header
+}
+
+// setHeader adds the headers contributed by p to h.
+// The headers replace existing values.
+func setHeader(h http.Header, p Problem) {
+	if p, ok := p.(HeaderProblem); ok {
+		for k, v := range p.ProblemHeader() {
+			h[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+	if p, ok := p.(RetryAfterProblem); ok {
+		if d := p.RetryAfter(); d > 0 {
+			h.Set("Retry-After", strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10))
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package problems
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+)
+
+// HTMLTemplate renders problems for browsers. It is executed with an HTMLView.
+// Replace it to change the look of the error pages.
+var HTMLTemplate = template.Must(template.New("problem").Parse(defaultHTMLTemplate))
+
+// HTMLView is the data passed to HTMLTemplate.
+type HTMLView struct {
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+	Members  []HTMLMember // extension members, sorted by name
+}
+
+// HTMLMember is an extension member of a problem.
+// Values that are not strings are encoded as json.
+type HTMLMember struct {
+	Name  string
+	Value string
+}
+
+const defaultHTMLTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="color-scheme" content="dark light">
+<title>{{.Status}} {{.Title}}</title>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+{{- if .Detail}}
+<p>{{.Detail}}</p>
+{{- end}}
+<dl>
+<dt>type</dt>
+<dd>{{if eq .Type "about:blank"}}{{.Type}}{{else}}<a href="{{.Type}}">{{.Type}}</a>{{end}}</dd>
+<dt>status</dt>
+<dd>{{.Status}}</dd>
+{{- if .Instance}}
+<dt>instance</dt>
+<dd>{{.Instance}}</dd>
+{{- end}}
+{{- range .Members}}
+<dt>{{.Name}}</dt>
+<dd>{{.Value}}</dd>
+{{- end}}
+</dl>
+</main>
+</body>
+</html>
+`
+
+var htmlFormat = &format{
+	name:        "html",
+	contentType: "text/html",
+	mediaTypes:  []string{"text/html", "application/xhtml+xml"},
+	write:       writeHTML,
+}
+
+func writeHTML(b *bytes.Buffer, p problem) error {
+	view := HTMLView{
+		Type:     p.typ,
+		Title:    p.title,
+		Status:   p.status,
+		Detail:   p.detail,
+		Instance: p.instance,
+		Members:  make([]HTMLMember, 0, len(p.data)),
+	}
+	for _, k := range sortedKeys(p.data) {
+		member := HTMLMember{Name: k}
+		if s, ok := p.data[k].(string); ok {
+			member.Value = s
+		} else {
+			v, err := json.Marshal(p.data[k])
+			if err != nil {
+				return err
+			}
+			member.Value = string(v)
+		}
+		view.Members = append(view.Members, member)
+	}
+	return HTMLTemplate.Execute(b, view)
+}
@@ -0,0 +1,138 @@
+// Package verbs counts the args that fmt consumes for a format string.
+package verbs
+
+import "unicode/utf8"
+
+// Scan returns the number of args that fmt consumes for format,
+// and the indexes of the args of %w verbs.
+// It follows the rules of fmt for flags, explicit argument indexes like %[2]d,
+// and '*' widths and precisions, which consume an arg of their own.
+func Scan(format string) (n int, wrapped []int) {
+	argNum := 0
+	use := func() {
+		argNum++
+		if argNum > n {
+			n = argNum
+		}
+	}
+	end := len(format)
+	for i := 0; i < end; {
+		if format[i] != '%' {
+			i++
+			continue
+		}
+		i++
+		// like fmt, a verb with a bad argument index does not consume an arg
+		goodArgNum := true
+
+		// flags
+		for i < end && isFlag(format[i]) {
+			i++
+		}
+
+		// argument index and width
+		var afterIndex, ok bool
+		argNum, i, afterIndex, ok = argNumber(argNum, format, i)
+		goodArgNum = goodArgNum && ok
+		if i < end && format[i] == '*' {
+			i++
+			use()
+			afterIndex = false
+		} else {
+			start := i
+			for i < end && '0' <= format[i] && format[i] <= '9' {
+				i++
+			}
+			if afterIndex && i > start {
+				// "%[3]2d"
+				goodArgNum = false
+			}
+		}
+
+		// precision
+		if i+1 < end && format[i] == '.' {
+			i++
+			if afterIndex {
+				// "%[3].2d"
+				goodArgNum = false
+			}
+			argNum, i, afterIndex, ok = argNumber(argNum, format, i)
+			goodArgNum = goodArgNum && ok
+			if i < end && format[i] == '*' {
+				i++
+				use()
+				afterIndex = false
+			} else {
+				for i < end && '0' <= format[i] && format[i] <= '9' {
+					i++
+				}
+			}
+		}
+
+		if !afterIndex {
+			argNum, i, _, ok = argNumber(argNum, format, i)
+			goodArgNum = goodArgNum && ok
+		}
+
+		if i >= end {
+			break
+		}
+		verb, size := utf8.DecodeRuneInString(format[i:])
+		i += size
+		if verb == '%' || !goodArgNum {
+			continue
+		}
+		if verb == 'w' {
+			wrapped = append(wrapped, argNum)
+		}
+		use()
+	}
+	return n, wrapped
+}
+
+func isFlag(c byte) bool {
+	switch c {
+	case '#', '0', '+', '-', ' ':
+		return true
+	}
+	return false
+}
+
+// argNumber parses an explicit argument index like [3] at format[i:], like fmt does.
+// Indexes are 1-based in the format and 0-based in the result.
+// found reports whether there is a well-formed index, ok whether it is valid.
+func argNumber(argNum int, format string, i int) (newArgNum, newi int, found, ok bool) {
+	if i >= len(format) || format[i] != '[' {
+		return argNum, i, false, true
+	}
+	if len(format)-i < 3 {
+		return argNum, i + 1, false, false
+	}
+	for j := i + 1; j < len(format); j++ {
+		if format[j] == ']' {
+			index, isNum := atoi(format[i+1 : j])
+			if !isNum {
+				return argNum, j + 1, false, false
+			}
+			if index < 1 {
+				return argNum, j + 1, true, false
+			}
+			return index - 1, j + 1, true, true
+		}
+	}
+	return argNum, i + 1, false, false
+}
+
+func atoi(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' || n > 1e6 {
+			return 0, false
+		}
+		n = n*10 + int(s[i]-'0')
+	}
+	return n, true
+}
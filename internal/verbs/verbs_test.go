@@ -0,0 +1,108 @@
+package verbs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// consumed returns the number of args that fmt.Sprintf consumes for format:
+// the fewest args that give the same output as plenty of args.
+func consumed(format string) int {
+	args := make([]any, 10)
+	for i := range args {
+		args[i] = i + 1
+	}
+	want := stripExtra(fmt.Sprintf(format, args...))
+	for k := 0; k < len(args); k++ {
+		if stripExtra(fmt.Sprintf(format, args[:k]...)) == want {
+			return k
+		}
+	}
+	return len(args)
+}
+
+func stripExtra(s string) string {
+	if i := strings.Index(s, "%!(EXTRA "); i != -1 {
+		return s[:i]
+	}
+	return s
+}
+
+func TestScan(t *testing.T) {
+	formats := []string{
+		"",
+		"no verbs",
+		"%%",
+		"%d",
+		"%d %s",
+		"%-+# 0d",
+		"%5.2f",
+		"%*d",
+		"%.*f",
+		"%*.*f",
+		"%[2]d %[1]d",
+		"%[2]d %d",
+		"%[3]*.[2]*[1]f",
+		"%[0]d x",
+		"%[0]d %d",
+		"%[x]d %d",
+		"%[]d %d",
+		"%[1",
+		"%[3]2d %d",
+		"%[2].2d %d",
+		"%d %",
+		"%5.",
+		"%!",
+		"%é %d",
+		"%[1]d %[1]d",
+		"%[9999999999]d %d",
+	}
+	for _, format := range formats {
+		if n, _ := Scan(format); n != consumed(format) {
+			t.Errorf("Scan(%q) = %d, fmt consumes %d", format, n, consumed(format))
+		}
+	}
+}
+
+func TestScanWrapped(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []int
+	}{
+		{"%d", nil},
+		{"%w", []int{0}},
+		{"%d: %w", []int{1}},
+		{"%[2]w %[1]w", []int{1, 0}},
+		{"%[0]w %w", []int{0}},
+		{"%*w", []int{1}},
+	}
+	for _, test := range tests {
+		if _, wrapped := Scan(test.format); !reflect.DeepEqual(wrapped, test.want) {
+			t.Errorf("Scan(%q) wrapped %v, want %v", test.format, wrapped, test.want)
+		}
+		// check the indexes against fmt.Errorf
+		args := make([]any, 3)
+		for i := range args {
+			args[i] = errors.New(fmt.Sprint(i))
+		}
+		err := fmt.Errorf(test.format, args...)
+		for i, arg := range args {
+			want := containsInt(test.want, i)
+			if got := errors.Is(err, arg.(error)); got != want {
+				t.Errorf("fmt.Errorf(%q) wraps arg %d: %v, want %v", test.format, i, got, want)
+			}
+		}
+	}
+}
+
+func containsInt(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package problems
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// Logger receives the errors that can not be returned to the caller,
+// like problems that can not be marshalled. If nil, slog.Default is used.
+var Logger *slog.Logger
+
+// LogProblems makes ServeProblem and Serve log every served problem to Logger,
+// at level error for 5xx statuses and at level info otherwise.
+var LogProblems = false
+
+func logger() *slog.Logger {
+	if Logger != nil {
+		return Logger
+	}
+	return slog.Default()
+}
+
+// logServed logs a served problem if LogProblems is set. req may be nil.
+func logServed(req *http.Request, p Problem, status int) {
+	if !LogProblems {
+		return
+	}
+	level := slog.LevelInfo
+	if status >= 500 {
+		level = slog.LevelError
+	}
+	attrs := []slog.Attr{problemAttr(p), slog.Int("status", status)}
+	if req != nil {
+		attrs = append(attrs, slog.String("method", req.Method), slog.String("path", req.URL.Path))
+	}
+	logger().LogAttrs(contextOf(req), level, "problem: served problem", attrs...)
+}
+
+func contextOf(req *http.Request) context.Context {
+	if req != nil {
+		return req.Context()
+	}
+	return context.Background()
+}
+
+func problemAttr(p Problem) slog.Attr {
+	if v, ok := p.(slog.LogValuer); ok {
+		return slog.Any("problem", v)
+	}
+	return slog.Any("problem", ProblemValue(p))
+}
+
+// ProblemValue returns the members of p as a slog group value.
+func ProblemValue(p Problem) slog.Value {
+	typ, title, status, detail, instance, data := p.Problem()
+	attrs := []slog.Attr{
+		slog.String("type", typ),
+		slog.String("title", title),
+		slog.Int("status", status),
+	}
+	if detail != "" {
+		attrs = append(attrs, slog.String("detail", detail))
+	}
+	if instance != "" {
+		attrs = append(attrs, slog.String("instance", instance))
+	}
+	if len(data) != 0 {
+		members := make([]any, 0, len(data))
+		for _, k := range sortedKeys(data) {
+			members = append(members, slog.Any(k, data[k]))
+		}
+		attrs = append(attrs, slog.Group("data", members...))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements the slog.LogValuer interface.
+// The wrapped errors are logged as a list of messages, outermost first,
+// following both Unwrap() error and Unwrap() []error depth first.
+func (e Error) LogValue() slog.Value {
+	v := ProblemValue(e)
+	if e.Wraps == nil {
+		return v
+	}
+	return slog.GroupValue(append(v.Group(), slog.Any("wraps", unwrapChain(nil, e.Wraps)))...)
+}
+
+// unwrapChain appends the messages of err and the errors it wraps to chain.
+func unwrapChain(chain []string, err error) []string {
+	for err != nil {
+		chain = append(chain, err.Error())
+		if u, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range u.Unwrap() {
+				chain = unwrapChain(chain, err)
+			}
+			return chain
+		}
+		err = errors.Unwrap(err)
+	}
+	return chain
+}
+
+// LogValue implements the slog.LogValuer interface.
+func (c *Composite) LogValue() slog.Value {
+	v := ProblemValue(c)
+	var attrs []slog.Attr
+	for _, a := range v.Group() {
+		if a.Key != "data" {
+			attrs = append(attrs, a)
+			continue
+		}
+		var members []any
+		for _, m := range a.Value.Group() {
+			if m.Key != "errors" {
+				members = append(members, m)
+			}
+		}
+		if len(members) != 0 {
+			attrs = append(attrs, slog.Group("data", members...))
+		}
+	}
+	problems := make([]any, len(c.Problems))
+	for i, p := range c.Problems {
+		problems[i] = slog.Attr{Key: strconv.Itoa(i), Value: problemAttr(p).Value}
+	}
+	return slog.GroupValue(append(attrs, slog.Group("errors", problems...))...)
+}
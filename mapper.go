@@ -0,0 +1,127 @@
+package problems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Problems returned by the built-in mappers. They wrap the original error.
+var (
+	ErrDeadlineExceeded = &Error{Type: "deadline-exceeded", Status: http.StatusGatewayTimeout, Title: "The request took too long to complete."}
+	ErrCanceled         = &Error{Type: "canceled", Status: 499, Title: "The request was canceled."}
+	ErrNotFound         = &Error{Type: "not-found", Status: http.StatusNotFound, Title: "The requested resource does not exist."}
+	ErrInvalidJSON      = &Error{Type: "invalid-json", Status: http.StatusBadRequest, Title: "The request contains invalid JSON."}
+	ErrInvalidJSONType  = &Error{Type: "invalid-json-type", Status: http.StatusBadRequest, Title: "The request contains a JSON value of the wrong type."}
+	ErrRequestTooLarge  = &Error{Type: "request-too-large", Status: http.StatusRequestEntityTooLarge, Title: "The request body is too large."}
+	ErrInvalidNumber    = &Error{Type: "invalid-number", Status: http.StatusBadRequest, Title: "The request contains an invalid number."}
+)
+
+// A Mapper converts an error to a Problem.
+// It returns nil for errors that it does not handle.
+type Mapper func(err error) Problem
+
+var mappersMu sync.RWMutex
+var mappers []Mapper
+
+// builtinMappers are consulted after the registered mappers.
+var builtinMappers = []Mapper{
+	mapInvalidParams,
+	mapContextError,
+	mapNotExist,
+	mapJSONError,
+	mapMaxBytesError,
+	mapNumError,
+}
+
+// RegisterMapper adds m to the mappers used by MapError.
+// Mappers registered later take precedence over mappers registered earlier.
+func RegisterMapper(m Mapper) {
+	mappersMu.Lock()
+	mappers = append(mappers, m)
+	mappersMu.Unlock()
+}
+
+// MapError returns the Problem for err, or nil if there is none. In order of precedence:
+//  1. the first Problem in the chain of err
+//  2. the registered mappers, starting with the one registered last
+//  3. the built-in mappers for InvalidParam errors and errors from the standard library
+func MapError(err error) Problem {
+	var p Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	mappersMu.RLock()
+	for i := len(mappers) - 1; i >= 0; i-- {
+		if p = mappers[i](err); p != nil {
+			break
+		}
+	}
+	mappersMu.RUnlock()
+	if p != nil {
+		return p
+	}
+	for _, m := range builtinMappers {
+		if p = m(err); p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+func mapContextError(err error) Problem {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrDeadlineExceeded.Wrap(err)
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrCanceled.Wrap(err)
+	}
+	return nil
+}
+
+func mapNotExist(err error) Problem {
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound.Wrap(err)
+	}
+	return nil
+}
+
+func mapJSONError(err error) Problem {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return ErrInvalidJSON.Errorf("Invalid JSON at offset %d: %s.", syntaxErr.Offset, syntaxErr.Error(), "offset", syntaxErr.Offset, err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		expected := typeErr.Type.String()
+		if typeErr.Field == "" {
+			return ErrInvalidJSONType.Errorf("Expected %s but got %s.", expected, typeErr.Value, "expected", expected, "value", typeErr.Value, "offset", typeErr.Offset, err)
+		}
+		return ErrInvalidJSONType.Errorf("Expected %s for field %q but got %s.", expected, typeErr.Field, typeErr.Value, "field", typeErr.Field, "expected", expected, "value", typeErr.Value, "offset", typeErr.Offset, err)
+	}
+	return nil
+}
+
+func mapMaxBytesError(err error) Problem {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return ErrRequestTooLarge.Errorf("The request body exceeds the limit of %d bytes.", maxBytesErr.Limit, "limit", maxBytesErr.Limit, err)
+	}
+	return nil
+}
+
+func mapNumError(err error) Problem {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		reason := "invalid syntax"
+		if errors.Is(numErr.Err, strconv.ErrRange) {
+			reason = "value out of range"
+		}
+		return ErrInvalidNumber.Errorf("Can not parse %q as a number: %s.", numErr.Num, reason, "value", numErr.Num, "reason", reason, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package problems
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrMultiple is the umbrella problem of a Composite.
+var ErrMultiple = &Error{Type: "multiple-problems", Title: "Multiple problems occurred."}
+
+// StatusPolicy derives the status of a Composite from the http statuses of its problems.
+type StatusPolicy func(statuses []int) int
+
+// DefaultStatusPolicy is used by composites without a policy.
+var DefaultStatusPolicy StatusPolicy = MostSevere
+
+// MultiStatus always returns 207 Multi-Status, leaving the statuses to the problems.
+func MultiStatus(statuses []int) int {
+	return http.StatusMultiStatus
+}
+
+// MostSevere returns the status that all problems share.
+// Otherwise it returns the generic status of the most severe class, 500 or 400.
+func MostSevere(statuses []int) int {
+	if len(statuses) == 0 {
+		return http.StatusInternalServerError
+	}
+	max, same := statuses[0], true
+	for _, status := range statuses[1:] {
+		if status != statuses[0] {
+			same = false
+		}
+		if status > max {
+			max = status
+		}
+	}
+	if same {
+		return max
+	}
+	return max / 100 * 100
+}
+
+// Composite is a problem made of several problems, for example the failed items of a batch request.
+// The problems are served in the "errors" member of an umbrella problem.
+// Decoding such a document with Decode yields an Error whose Problems method returns the problems.
+type Composite struct {
+	Type     string // defaults to the type of ErrMultiple
+	Title    string // defaults to the title of ErrMultiple
+	Detail   string
+	Instance string
+	Problems []Problem
+	Policy   StatusPolicy // defaults to DefaultStatusPolicy
+
+	// members and headers added by the Enrichers
+	data   map[string]any
+	header http.Header
+}
+
+// Join returns a Composite of the given problems.
+func Join(problems ...Problem) *Composite {
+	return &Composite{Problems: problems}
+}
+
+// Error implements the error interface.
+func (c *Composite) Error() string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(len(c.Problems)) + " problems")
+	for i, p := range c.Problems {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		typ, title, _, detail, _, _ := p.Problem()
+		switch {
+		case detail != "":
+			b.WriteString(detail)
+		case title != "":
+			b.WriteString(title)
+		default:
+			b.WriteString(typ)
+		}
+	}
+	return b.String()
+}
+
+// Unwrap returns the problems that are errors, so that errors.Is and errors.As find them.
+func (c *Composite) Unwrap() []error {
+	var errs []error
+	for _, p := range c.Problems {
+		if err, ok := p.(error); ok {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Problem implements the Problem interface.
+func (c *Composite) Problem() (typ string, title string, status int, detail string, instance string, data map[string]any) {
+	docs := make([]problem, len(c.Problems))
+	statuses := make([]int, len(c.Problems))
+	for i, p := range c.Problems {
+		doc, err := newProblem(p)
+		if err != nil {
+			logger().Error("problem: can not serve problem", "error", err)
+			doc, _ = newProblem(ErrInternalServerError)
+		}
+		docs[i] = doc
+		statuses[i] = doc.status
+	}
+
+	policy := c.Policy
+	if policy == nil {
+		policy = DefaultStatusPolicy
+	}
+	typ, title = c.Type, c.Title
+	if typ == "" {
+		typ = ErrMultiple.Type
+	}
+	if title == "" {
+		title = ErrMultiple.Title
+	}
+	data = make(map[string]any, len(c.data)+1)
+	for k, v := range c.data {
+		data[k] = v
+	}
+	data["errors"] = docs
+	return typ, title, policy(statuses), c.Detail, c.Instance, data
+}
+
+// ProblemHeader implements the HeaderProblem interface.
+func (c *Composite) ProblemHeader() http.Header {
+	return c.header
+}
+
+// enrich returns a copy of c with the Enrichers applied to the umbrella problem and to each of its problems.
+func (c *Composite) enrich(req *http.Request) *Composite {
+	e := &Error{
+		Type:     c.Type,
+		Title:    c.Title,
+		Detail:   c.Detail,
+		Instance: c.Instance,
+		Header:   c.header.Clone(),
+		Data:     make(map[string]any, len(c.data)+2),
+	}
+	if e.Type == "" {
+		e.Type = ErrMultiple.Type
+	}
+	if e.Header == nil {
+		e.Header = make(http.Header)
+	}
+	for k, v := range c.data {
+		e.Data[k] = v
+	}
+	for _, enricher := range Enrichers {
+		enricher(req, e)
+	}
+	delete(e.Data, "errors")
+
+	problems := make([]Problem, len(c.Problems))
+	for i, p := range c.Problems {
+		problems[i] = enrich(req, p)
+	}
+	return &Composite{
+		Type:     e.Type,
+		Title:    e.Title,
+		Detail:   e.Detail,
+		Instance: e.Instance,
+		Problems: problems,
+		Policy:   c.Policy,
+		data:     e.Data,
+		header:   e.Header,
+	}
+}
+
+// Problems decodes the problems in the "errors" member of a decoded Composite.
+// It returns nil if the member is not a list of objects with a type or status member.
+// Data keeps the member as it was decoded, so that it is served unchanged.
+func (e Error) Problems() []Problem {
+	return decodeProblems(e.Data["errors"])
+}
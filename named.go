@@ -0,0 +1,73 @@
+package problems
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/halliday/go-problems/internal/verbs"
+)
+
+// Pnamedf is like Pprintf, but the placeholders in the template refer to the key-value args by name:
+//
+//	Pnamedf("Cannot withdraw {requestedAmount:%.2f} of {availableAmount}.", "requestedAmount", 10.0, "availableAmount", 5)
+//
+// A placeholder is replaced by the value of the arg, formatted with the verb after the colon or with %v.
+// The verb must format exactly one arg and can not be %w.
+// Every arg is returned in data, and a trailing error arg is returned as wraps.
+// Use {{ and }} for literal braces.
+// Unknown names, malformed templates and malformed args are returned as err,
+// but malformed args are recorded under BadKey if Lenient is set.
+func Pnamedf(template string, args ...any) (str string, data map[string]any, wraps error, err error) {
+	if len(args) > 0 {
+		var ok bool
+		wraps, ok = args[len(args)-1].(error)
+		if ok {
+			args = args[:len(args)-1]
+		}
+	}
+	if data, err = denseArgs(args, Lenient); err != nil {
+		return "", nil, nil, err
+	}
+
+	var b strings.Builder
+	for len(template) > 0 {
+		i := strings.IndexAny(template, "{}")
+		if i == -1 {
+			b.WriteString(template)
+			break
+		}
+		b.WriteString(template[:i])
+		c := template[i]
+		template = template[i+1:]
+		if len(template) > 0 && template[0] == c {
+			// escaped brace
+			b.WriteByte(c)
+			template = template[1:]
+			continue
+		}
+		if c == '}' {
+			return "", nil, nil, errors.New("unmatched '}' in template")
+		}
+		end := strings.IndexByte(template, '}')
+		if end == -1 {
+			return "", nil, nil, errors.New("unmatched '{' in template")
+		}
+		name, verb, ok := strings.Cut(template[:end], ":")
+		template = template[end+1:]
+		if name == "" {
+			return "", nil, nil, errors.New("missing name in template placeholder")
+		}
+		if !ok {
+			verb = "%v"
+		} else if n, wrapped := verbs.Scan(verb); !strings.HasPrefix(verb, "%") || n != 1 || len(wrapped) != 0 {
+			return "", nil, nil, fmt.Errorf("invalid verb %q for %q in template, want a single verb other than %%w", verb, name)
+		}
+		v, ok := data[name]
+		if !ok {
+			return "", nil, nil, fmt.Errorf("unknown name %q in template", name)
+		}
+		fmt.Fprintf(&b, verb, v)
+	}
+	return b.String(), data, wraps, nil
+}
@@ -0,0 +1,82 @@
+package problems
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Serve is like ServeProblem, but negotiates the format of the problem document
+// with the Accept header of req. It can serve json, xml, html and plain text.
+// If the client accepts none of them, json is served.
+func Serve(resp http.ResponseWriter, req *http.Request, p Problem) {
+	resp.Header().Add("Vary", "Accept")
+	serveProblem(resp, req, p, negotiate(req.Header.Get("Accept")))
+}
+
+// negotiate returns the format with the highest quality in accept.
+// Ties are broken by the order of formats.
+func negotiate(accept string) *format {
+	if accept == "" {
+		return formats[0]
+	}
+	ranges := parseAccept(accept)
+	best, bestQ := formats[0], 0.0
+	for _, f := range formats {
+		for _, mediaType := range f.mediaTypes {
+			if q := quality(ranges, mediaType); q > bestQ {
+				best, bestQ = f, q
+			}
+		}
+	}
+	return best
+}
+
+type mediaRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, s := range strings.Split(accept, ",") {
+		params := strings.Split(s, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok {
+			continue
+		}
+		r := mediaRange{typ: typ, subtype: subtype, q: 1}
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil && q >= 0 && q <= 1 {
+					r.q = q
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// quality returns the quality of the most specific range that matches mediaType.
+func quality(ranges []mediaRange, mediaType string) float64 {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
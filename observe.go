@@ -0,0 +1,149 @@
+package problems
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Observer is notified of every problem served by ServeProblem, Serve
+// and the handlers of this package, with the http status of the response.
+// The request is nil for ServeProblem.
+type Observer interface {
+	ObserveProblem(req *http.Request, p Problem, status int)
+}
+
+// ObserverFunc is a function that implements the Observer interface.
+// It makes it easy to feed metrics libraries:
+//
+//	problems.AddObserver(problems.ObserverFunc(func(req *http.Request, p problems.Problem, status int) {
+//		typ, _, _, _, _, _ := p.Problem()
+//		served.WithLabelValues(typ, strconv.Itoa(status)).Inc()
+//	}))
+type ObserverFunc func(req *http.Request, p Problem, status int)
+
+// ObserveProblem implements the Observer interface.
+func (f ObserverFunc) ObserveProblem(req *http.Request, p Problem, status int) {
+	f(req, p, status)
+}
+
+var observersMu sync.RWMutex
+var observers []Observer
+
+// AddObserver adds o to the observers of served problems.
+func AddObserver(o Observer) {
+	observersMu.Lock()
+	observers = append(observers, o)
+	observersMu.Unlock()
+}
+
+// observe notifies the observers. req may be nil.
+func observe(req *http.Request, p Problem, status int) {
+	observersMu.RLock()
+	defer observersMu.RUnlock()
+	for _, o := range observers {
+		o.ObserveProblem(req, p, status)
+	}
+}
+
+// ExpvarObserver counts served problems in expvar variables.
+type ExpvarObserver struct {
+	Total    *expvar.Int
+	Types    *expvar.Map // by problem type
+	Statuses *expvar.Map // by http status
+}
+
+// NewExpvarObserver returns an ExpvarObserver that is published as an expvar.Map
+// with the given name, holding the members "total", "type" and "status".
+// Like expvar.Publish, it panics if the name is already in use.
+func NewExpvarObserver(name string) *ExpvarObserver {
+	o := &ExpvarObserver{
+		Total:    new(expvar.Int),
+		Types:    new(expvar.Map).Init(),
+		Statuses: new(expvar.Map).Init(),
+	}
+	m := expvar.NewMap(name)
+	m.Set("total", o.Total)
+	m.Set("type", o.Types)
+	m.Set("status", o.Statuses)
+	return o
+}
+
+// ObserveProblem implements the Observer interface.
+func (o *ExpvarObserver) ObserveProblem(req *http.Request, p Problem, status int) {
+	typ, _, _, _, _, _ := p.Problem()
+	o.Total.Add(1)
+	o.Types.Add(typ, 1)
+	o.Statuses.Add(strconv.Itoa(status), 1)
+}
+
+// Metrics counts served problems by type and status in a local registry,
+// and serves the counters in the Prometheus text exposition format.
+// The zero value is ready to use.
+type Metrics struct {
+	Name string // defaults to "problems_served_total"
+
+	mu     sync.Mutex
+	counts map[metricKey]uint64
+}
+
+type metricKey struct {
+	typ    string
+	status int
+}
+
+// ObserveProblem implements the Observer interface.
+func (m *Metrics) ObserveProblem(req *http.Request, p Problem, status int) {
+	typ, _, _, _, _, _ := p.Problem()
+	m.mu.Lock()
+	if m.counts == nil {
+		m.counts = make(map[metricKey]uint64)
+	}
+	m.counts[metricKey{typ, status}]++
+	m.mu.Unlock()
+}
+
+// Count returns the number of served problems with the given type and status.
+func (m *Metrics) Count(typ string, status int) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[metricKey{typ, status}]
+}
+
+// ServeHTTP serves the counters, so that m can be scraped by Prometheus.
+func (m *Metrics) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	name := m.Name
+	if name == "" {
+		name = "problems_served_total"
+	}
+
+	m.mu.Lock()
+	keys := make([]metricKey, 0, len(m.counts))
+	for k := range m.counts {
+		keys = append(keys, k)
+	}
+	counts := make([]uint64, len(keys))
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].typ != keys[j].typ {
+			return keys[i].typ < keys[j].typ
+		}
+		return keys[i].status < keys[j].status
+	})
+	for i, k := range keys {
+		counts[i] = m.counts[k]
+	}
+	m.mu.Unlock()
+
+	resp.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprintf(resp, "# HELP %s Number of served problems by type and status.\n", name)
+	fmt.Fprintf(resp, "# TYPE %s counter\n", name)
+	for i, k := range keys {
+		fmt.Fprintf(resp, "%s{type=\"%s\",status=\"%d\"} %d\n", name, labelEscaper.Replace(k.typ), k.status, counts[i])
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
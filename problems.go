@@ -1,13 +1,14 @@
 package problems
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"reflect"
-	"strings"
+
+	"github.com/halliday/go-problems/internal/verbs"
 )
 
 type Problem interface {
@@ -15,34 +16,89 @@ type Problem interface {
 }
 
 func ServeProblem(resp http.ResponseWriter, p Problem) {
+	serveProblem(resp, nil, p, jsonFormat)
+}
+
+// serveProblem writes p in the format f, enriched for req. req may be nil.
+func serveProblem(resp http.ResponseWriter, req *http.Request, p Problem, f *format) {
+	p = enrich(req, p)
+	doc, err := newProblem(p)
+	if err != nil {
+		logger().ErrorContext(contextOf(req), "problem: can not serve problem", "error", err)
+		p, doc = internalProblem(req)
+	}
+
+	var b bytes.Buffer
+	if err := f.write(&b, doc); err != nil {
+		logger().ErrorContext(contextOf(req), "problem: can not marshal problem", "format", f.name, problemAttr(p), "error", err)
+		p, doc = internalProblem(req)
+		b.Reset()
+		f.write(&b, doc)
+	}
+
+	setHeader(resp.Header(), p)
+	logServed(req, p, doc.status)
+	observe(req, p, doc.status)
+
+	markServing(resp)
+	resp.Header().Set("X-Content-Type-Options", "nosniff")
+	resp.Header().Set("Content-Type", f.contentType+"; charset=utf-8")
+	resp.WriteHeader(doc.status)
+	resp.Write(b.Bytes())
+}
+
+// internalProblem returns ErrInternalServerError enriched for req, with its document,
+// to be served when the problem at hand can not be.
+func internalProblem(req *http.Request) (Problem, problem) {
+	p := enrich(req, ErrInternalServerError)
+	doc, err := newProblem(p)
+	if err != nil {
+		doc = problem{typ: "about:blank", title: "Internal Server Error", status: http.StatusInternalServerError}
+	}
+	return p, doc
+}
+
+// newProblem returns the document for p, with the http status derived by StatusFunc.
+func newProblem(p Problem) (problem, error) {
 	typ, title, status, detail, instance, data := p.Problem()
 
-	statusCode := status
-	for statusCode > 1000 {
-		statusCode /= 10
+	status, code, err := StatusFunc(status)
+	if err == nil && (status < 200 || status > 599) {
+		err = fmt.Errorf("invalid http status %d", status)
+	}
+	if err != nil {
+		return problem{}, fmt.Errorf("type %q: %w", typ, err)
+	}
+	if _, ok := data["code"]; code != 0 && !ok {
+		data = withMember(data, "code", code)
 	}
 
 	if typ == "" {
 		typ = "about:blank"
 	}
 
-	resp.Header().Set("X-Content-Type-Options", "nosniff")
-	resp.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
-	resp.WriteHeader(statusCode)
+	if data, err = resolveCollisions(typ, data); err != nil {
+		return problem{}, err
+	}
 
-	encoder := json.NewEncoder(resp)
-	encoder.SetIndent("", "  ")
-	err := encoder.Encode(problem{
+	return problem{
 		typ:      typ,
 		title:    title,
 		status:   status,
 		detail:   detail,
 		instance: instance,
 		data:     data,
-	})
-	if err != nil {
-		log.Printf("problem: can not marshal problem as json: %v, error: %v", p, err)
+	}, nil
+}
+
+// withMember returns a copy of data with the member k set to v.
+func withMember(data map[string]any, k string, v any) map[string]any {
+	m := make(map[string]any, len(data)+1)
+	for k, v := range data {
+		m[k] = v
 	}
+	m[k] = v
+	return m
 }
 
 type problem struct {
@@ -54,97 +110,195 @@ type problem struct {
 	data     map[string]any
 }
 
+// MarshalJSON writes the standard members in the order of RFC 7807,
+// followed by the extension members sorted by name.
 func (p problem) MarshalJSON() ([]byte, error) {
-	m := make(map[string]any)
-	m["type"] = p.typ
-	m["title"] = p.title
-	m["status"] = p.status
+	var b bytes.Buffer
+	b.WriteByte('{')
+	writeMember(&b, "type", p.typ)
+	writeMember(&b, "title", p.title)
+	writeMember(&b, "status", p.status)
 	if p.detail != "" {
-		m["detail"] = p.detail
+		writeMember(&b, "detail", p.detail)
 	}
 	if p.instance != "" {
-		m["instance"] = p.instance
+		writeMember(&b, "instance", p.instance)
 	}
-	for k, v := range p.data {
-		m[k] = v
+	for _, k := range sortedKeys(p.data) {
+		if reserved[k] {
+			continue
+		}
+		if err := writeMember(&b, k, p.data[k]); err != nil {
+			return nil, fmt.Errorf("member %q: %w", k, err)
+		}
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func writeMember(b *bytes.Buffer, k string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
 	}
-	return json.Marshal(m)
+	if b.Len() > 1 {
+		b.WriteByte(',')
+	}
+	key, _ := json.Marshal(k)
+	b.Write(key)
+	b.WriteByte(':')
+	b.Write(value)
+	return nil
 }
 
+// Lenient makes Pprintf and Pnamedf record malformed args in data under BadKey,
+// like log/slog does, instead of panicking or failing.
+// Missing args of format verbs are printed as %!d(MISSING) by fmt.
+var Lenient = false
+
+// BadKey is the key of malformed args in Lenient mode.
+// If there is more than one, they are recorded as a list.
+const BadKey = "!BADKEY"
+
+// Pprintf formats the leading args according to format, like fmt.Errorf,
+// and returns the remaining args as data.
+// The args of %w verbs and a trailing error arg are returned as wraps,
+// joined with errors.Join if there is more than one.
+// Pprintf panics on malformed args, unless Lenient is set.
 func Pprintf(format string, args ...any) (str string, data map[string]any, wraps error) {
-	n := numArgsPattern(format)
+	str, data, wraps, err := pprintf(format, args, Lenient)
+	if err != nil {
+		panic(err)
+	}
+	return str, data, wraps
+}
+
+// TryPprintf is like Pprintf, but returns malformed args as err instead of panicking.
+func TryPprintf(format string, args ...any) (str string, data map[string]any, wraps error, err error) {
+	return pprintf(format, args, false)
+}
+
+func pprintf(format string, args []any, lenient bool) (str string, data map[string]any, wraps error, err error) {
+	n, wrapped := verbs.Scan(format)
 	if len(args) < n {
-		panic(fmt.Sprintf("pattern has %d args for %d placeholders", len(args), n))
+		if !lenient {
+			return "", nil, nil, fmt.Errorf("pattern has %d args for %d placeholders", len(args), n)
+		}
+		n = len(args)
 	}
-	if n != 0 {
-		str = fmt.Sprintf(format, args[:n]...)
+	var errs []error
+	if n != 0 || len(wrapped) != 0 {
+		if len(wrapped) != 0 {
+			str = fmt.Errorf(format, args[:n]...).Error()
+			for j, i := range wrapped {
+				if i >= n {
+					continue
+				}
+				if err, ok := args[i].(error); ok && !containsInt(wrapped[:j], i) {
+					errs = append(errs, err)
+				}
+			}
+		} else {
+			str = fmt.Sprintf(format, args[:n]...)
+		}
 		args = args[n:]
 	} else {
 		str = format
 	}
 	if len(args) > 0 {
-		var ok bool
-		wraps, ok = args[len(args)-1].(error)
-		if ok {
+		if err, ok := args[len(args)-1].(error); ok {
+			errs = append(errs, err)
 			args = args[:len(args)-1]
 		}
 	}
-	data = denseArgs(args...)
-	return str, data, wraps
+	switch len(errs) {
+	case 0:
+	case 1:
+		wraps = errs[0]
+	default:
+		wraps = errors.Join(errs...)
+	}
+	if data, err = denseArgs(args, lenient); err != nil {
+		return "", nil, nil, err
+	}
+	return str, data, wraps, nil
 }
 
-func numArgsPattern(s string) int {
-	n := 0
-	for {
-		i := strings.IndexByte(s, '%')
-		if i == -1 {
-			return n
-		}
-		s = s[i+1:]
-		if len(s) > 0 && s[0] == '%' {
-			s = s[1:]
-		} else {
-			n++
+func containsInt(s []int, i int) bool {
+	for _, j := range s {
+		if j == i {
+			return true
 		}
 	}
+	return false
 }
 
-func denseArgs(args ...any) map[string]any {
+// DenseArgs converts key-value pairs, a single map or a single struct to data,
+// like Pprintf does with the args that follow the format args.
+// Structs are converted like encoding/json does, honouring json tags and flattening embedded structs.
+func DenseArgs(args ...any) (map[string]any, error) {
+	return denseArgs(args, false)
+}
 
+func denseArgs(args []any, lenient bool) (map[string]any, error) {
 	switch len(args) {
 	case 0:
-		return nil
+		return nil, nil
 	case 1:
 		if m, ok := args[0].(map[string]any); ok {
-			return m
+			return m, nil
 		}
 		r := reflect.ValueOf(args[0])
 		for r.Kind() == reflect.Ptr {
 			r = r.Elem()
 		}
 		if r.Kind() != reflect.Struct {
-			panic(fmt.Errorf("invalid key[0]: unsupported type %t", args[0]))
-		}
-		m := make(map[string]any)
-		for j := 0; j < r.NumField(); j++ {
-			f := r.Field(j)
-			if f.CanInterface() {
-				m[r.Type().Field(j).Name] = f.Interface()
+			if lenient {
+				return map[string]any{BadKey: args[0]}, nil
 			}
+			return nil, fmt.Errorf("invalid key[0]: unsupported type %T", args[0])
 		}
-		return m
+		return structData(r), nil
 	default:
 		m := make(map[string]any)
+		if lenient {
+			// like log/slog: a value without a string key is recorded under BadKey,
+			// and the next arg is taken as a key
+			for i := 0; i < len(args); i++ {
+				if key, ok := args[i].(string); ok && i+1 < len(args) {
+					m[key] = args[i+1]
+					i++
+				} else {
+					addBadArg(m, args[i])
+				}
+			}
+			return m, nil
+		}
 		if len(args)%2 != 0 {
-			panic(errors.New("missing unmatched key-value pairs"))
+			return nil, errors.New("missing unmatched key-value pairs")
 		}
 		for i := 0; i < len(args); i += 2 {
 			if key, ok := args[i].(string); ok {
 				m[key] = args[i+1]
 			} else {
-				panic(fmt.Errorf("invalid key[%d]: unsupported type %t", i, args[i]))
+				return nil, fmt.Errorf("invalid key[%d]: unsupported type %T", i, args[i])
 			}
 		}
-		return m
+		return m, nil
+	}
+}
+
+func addBadArg(m map[string]any, v any) {
+	switch bad := m[BadKey].(type) {
+	case nil:
+		if _, ok := m[BadKey]; !ok {
+			m[BadKey] = v
+			return
+		}
+		m[BadKey] = []any{nil, v}
+	case []any:
+		m[BadKey] = append(bad, v)
+	default:
+		m[BadKey] = []any{bad, v}
 	}
 }
@@ -0,0 +1,32 @@
+package problems
+
+import (
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover returns a handler that recovers panics in h and serves ErrInternalServerError.
+// The panic and its stack trace are logged, but not sent to the client.
+// Panics with http.ErrAbortHandler are passed on. If h has already written the response header,
+// the response is aborted with http.ErrAbortHandler after logging, so that the client does not
+// mistake the truncated response for a complete one.
+func Recover(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		w := &headerWriter{ResponseWriter: resp}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			logger().ErrorContext(req.Context(), "problem: panic", "method", req.Method, "path", req.URL.Path, "panic", v, "stack", string(debug.Stack()))
+			if w.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+			Serve(resp, req, ErrInternalServerError)
+		}()
+		h.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,128 @@
+package problems
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var registryMu sync.RWMutex
+var registry = make(map[string]Problem)
+
+// Register adds problems to the registry under their type, usually from an init function,
+// like the one generated by cmd/goproblems with -with-register.
+// A type that is already registered keeps its first problem, and a warning is logged.
+func Register(problems ...Problem) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, p := range problems {
+		typ, _, _, _, _, _ := p.Problem()
+		if typ == "" {
+			typ = "about:blank"
+		}
+		if _, ok := registry[typ]; ok {
+			logger().Warn("problem: type is already registered", "type", typ)
+			continue
+		}
+		registry[typ] = p
+	}
+}
+
+// Lookup returns the registered problem with the given type, or nil if there is none.
+func Lookup(typ string) Problem {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[typ]
+}
+
+// LookupStatus returns the registered problems that are served with the given http status,
+// sorted by type.
+func LookupStatus(status int) []Problem {
+	return lookup(func(typ string, p Problem) bool {
+		_, _, s, _, _, _ := p.Problem()
+		s, _, err := StatusFunc(s)
+		return err == nil && s == status
+	})
+}
+
+// LookupPrefix returns the registered problems whose type starts with prefix, sorted by type.
+func LookupPrefix(prefix string) []Problem {
+	return lookup(func(typ string, p Problem) bool {
+		return strings.HasPrefix(typ, prefix)
+	})
+}
+
+func lookup(match func(typ string, p Problem) bool) []Problem {
+	registryMu.RLock()
+	types := make([]string, 0, len(registry))
+	for typ, p := range registry {
+		if match(typ, p) {
+			types = append(types, typ)
+		}
+	}
+	problems := make([]Problem, len(types))
+	sort.Strings(types)
+	for i, typ := range types {
+		problems[i] = registry[typ]
+	}
+	registryMu.RUnlock()
+	return problems
+}
+
+// DecodeProblem is like Decode, but rehydrates the problem into its registered Go type with Rehydrate.
+func DecodeProblem(data []byte) (Problem, error) {
+	e, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return Rehydrate(e), nil
+}
+
+// DecodeResponseProblem is like DecodeResponse, but rehydrates the problem into its registered Go type with Rehydrate.
+func DecodeResponseProblem(resp *http.Response) (Problem, error) {
+	e, err := DecodeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	return Rehydrate(e), nil
+}
+
+var errorType = reflect.TypeOf(Error{})
+
+// Rehydrate returns e as a value of the concrete Go type that is registered for its type,
+// by encoding e as json and decoding it into a new value of that type.
+// It returns e itself if the type is not registered, is registered as an Error,
+// or if e can not be decoded into the registered type.
+func Rehydrate(e *Error) Problem {
+	p := Lookup(e.Type)
+	if p == nil {
+		return e
+	}
+	t := reflect.TypeOf(p)
+	ptr := t.Kind() == reflect.Pointer
+	if ptr {
+		t = t.Elem()
+	}
+	if t == errorType {
+		return e
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return e
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(b, v.Interface()); err != nil {
+		logger().Warn("problem: can not rehydrate problem", "type", e.Type, "error", err)
+		return e
+	}
+	if !ptr {
+		v = v.Elem()
+	}
+	if q, ok := v.Interface().(Problem); ok {
+		return q
+	}
+	return e
+}
@@ -0,0 +1,36 @@
+package problems
+
+import "fmt"
+
+// StatusFunc derives the http status of a served problem from the status the problem reports.
+// A non-zero code is served as the "code" extension member.
+// Problems with statuses that StatusFunc rejects are logged and served as ErrInternalServerError.
+var StatusFunc func(status int) (httpStatus int, code int, err error) = HTTPStatus
+
+// HTTPStatus accepts only http status codes from 200 to 599.
+// Informational 1xx statuses are rejected, because net/http sends them as interim responses
+// and the problem would then be sent with 200 OK.
+// Use the Code of Error for application specific codes.
+func HTTPStatus(status int) (httpStatus int, code int, err error) {
+	if status < 200 || status > 599 {
+		return 0, 0, fmt.Errorf("invalid http status %d", status)
+	}
+	return status, 0, nil
+}
+
+// LegacyStatus accepts application specific statuses that start with a 4xx or 5xx http status, like 4001.
+// The http status is the first three digits and the full status becomes the code.
+// Statuses up to 999 are accepted like HTTPStatus does.
+func LegacyStatus(status int) (httpStatus int, code int, err error) {
+	if status <= 999 {
+		return HTTPStatus(status)
+	}
+	httpStatus = status
+	for httpStatus > 999 {
+		httpStatus /= 10
+	}
+	if httpStatus < 400 || httpStatus > 599 {
+		return 0, 0, fmt.Errorf("invalid legacy status %d", status)
+	}
+	return httpStatus, status, nil
+}
@@ -0,0 +1,90 @@
+package problems
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ResponseError is the error returned by Transport for problem responses.
+// It unwraps to the decoded problem, so errors.Is matches it against Errors with the same type,
+// like the variables generated by cmd/goproblems.
+// If a concrete type is registered for the problem type, errors.As also finds the problem as that type.
+type ResponseError struct {
+	Err      *Error
+	Typed    Problem        // Err rehydrated with Rehydrate
+	Response *http.Response // with the status, headers, and the problem document as the body
+}
+
+// Error implements the error interface.
+func (r *ResponseError) Error() string {
+	return r.Err.Error()
+}
+
+// Unwrap returns the decoded problem and its rehydrated form, if that is a different error.
+func (r *ResponseError) Unwrap() []error {
+	if err, ok := r.Typed.(error); ok && r.Typed != Problem(r.Err) {
+		return []error{err, r.Err}
+	}
+	return []error{r.Err}
+}
+
+// Problem implements the Problem interface.
+func (r *ResponseError) Problem() (typ string, title string, status int, detail string, instance string, data map[string]any) {
+	return r.Err.Problem()
+}
+
+// Transport is an http.RoundTripper that returns error responses with a problem document
+// as a *ResponseError. Other responses are passed on unchanged.
+// Problems are decoded with DecodeResponse and rehydrated with Rehydrate.
+//
+// An http.Client wraps errors of its transport in a *url.Error,
+// which errors.Is and errors.As see through.
+type Transport struct {
+	Base http.RoundTripper // defaults to http.DefaultTransport
+
+	// BaseURI is stripped from the types of problems,
+	// so that they match the relative types of the generated variables.
+	BaseURI string
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 400 || !isProblemContentType(resp.Header.Get("Content-Type")) {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	e, err := DecodeResponse(resp)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		// not a json problem document, leave it to the caller
+		return resp, nil
+	}
+	if t.BaseURI != "" {
+		e.Type = strings.TrimPrefix(e.Type, t.BaseURI)
+	}
+	return nil, &ResponseError{Err: e, Typed: Rehydrate(e), Response: resp}
+}
+
+// Client returns a copy of c whose transport is wrapped in a Transport with the given BaseURI.
+// A nil c is treated as http.DefaultClient.
+func Client(c *http.Client, baseURI string) *http.Client {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	client := *c
+	client.Transport = &Transport{Base: c.Transport, BaseURI: baseURI}
+	return &client
+}
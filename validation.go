@@ -0,0 +1,107 @@
+package problems
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// ErrValidation is the problem for invalid request parameters.
+// The parameters are listed in the "invalid-params" member.
+var ErrValidation = &Error{Type: "validation-error", Status: http.StatusBadRequest, Title: "Your request parameters didn't validate."}
+
+// InvalidParam is the error for a single invalid request parameter.
+// Several of them can be combined with errors.Join or a Validation.
+type InvalidParam struct {
+	Name   string `json:"name"`           // JSON Pointer or query parameter name
+	Reason string `json:"reason"`         // human readable
+	Code   string `json:"code,omitempty"` // machine readable, optional
+}
+
+// Error implements the error interface.
+func (p *InvalidParam) Error() string {
+	return p.Name + ": " + p.Reason
+}
+
+// Validation collects invalid request parameters.
+type Validation struct {
+	Status int // defaults to the status of ErrValidation, use 422 for semantic errors
+	Params []*InvalidParam
+}
+
+// Add records an invalid parameter.
+func (v *Validation) Add(name, reason string) *Validation {
+	return v.AddCode(name, "", reason)
+}
+
+// AddCode records an invalid parameter with a machine readable code.
+func (v *Validation) AddCode(name, code, reason string) *Validation {
+	v.Params = append(v.Params, &InvalidParam{Name: name, Reason: reason, Code: code})
+	return v
+}
+
+// Err returns a copy of ErrValidation with the recorded parameters, or nil if there are none.
+func (v *Validation) Err() error {
+	if len(v.Params) == 0 {
+		return nil
+	}
+	e := newValidationError(v.Params)
+	if v.Status != 0 {
+		e.Status = v.Status
+	}
+	return e
+}
+
+// ValidationError returns a copy of ErrValidation with every InvalidParam in the tree of err,
+// including errors combined with errors.Join. It returns nil if there are none.
+func ValidationError(err error) *Error {
+	params := collectInvalidParams(nil, err)
+	if len(params) == 0 {
+		return nil
+	}
+	e := newValidationError(params)
+	e.Wraps = err
+	return e
+}
+
+func newValidationError(params []*InvalidParam) *Error {
+	detail := "1 parameter is invalid."
+	if len(params) != 1 {
+		detail = strconv.Itoa(len(params)) + " parameters are invalid."
+	}
+	e := ErrValidation.WithDetail(detail)
+	e.Data = withMember(e.Data, "invalid-params", params)
+	e.Wraps = errors.Join(paramErrors(params)...)
+	return e
+}
+
+func paramErrors(params []*InvalidParam) []error {
+	errs := make([]error, len(params))
+	for i, p := range params {
+		errs[i] = p
+	}
+	return errs
+}
+
+func collectInvalidParams(params []*InvalidParam, err error) []*InvalidParam {
+	switch err := err.(type) {
+	case nil:
+		return params
+	case *InvalidParam:
+		return append(params, err)
+	case interface{ Unwrap() error }:
+		return collectInvalidParams(params, err.Unwrap())
+	case interface{ Unwrap() []error }:
+		for _, err := range err.Unwrap() {
+			params = collectInvalidParams(params, err)
+		}
+	}
+	return params
+}
+
+func mapInvalidParams(err error) Problem {
+	if e := ValidationError(err); e != nil {
+		return e
+	}
+	return nil
+}